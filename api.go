@@ -9,6 +9,8 @@ type Service interface {
 	Version() string
 	Categories() []string
 	BaseURL() string
+	SetBaseURL(u string)
+	Servers() []Server
 	DocsURL() string
 	Orientation() string
 	Security() []string
@@ -16,6 +18,9 @@ type Service interface {
 	Resources() []Resource
 	Resource(name string) (Resource, error)
 
+	Webhooks() []Webhook
+	Webhook(name string) (Webhook, error)
+
 	Schema() *jsonaccess.Value
 	Meta() *jsonaccess.Value
 }
@@ -49,6 +54,7 @@ type Operation interface {
 	Description() string
 	URL() string
 	Method() string
+	Servers() []Server
 	Tags() []string
 	Orientation() string
 	DocsURL() string
@@ -56,11 +62,23 @@ type Operation interface {
 	Scopes() []string
 
 	Parameters() []Schema
+	Responses() map[string]map[string]Schema
 	Response() Schema
+	Inputs() map[string]Schema
 	Input() Schema
 	Output() Schema
 }
 
+type Webhook interface {
+	Service() Service
+	Name() string
+	ID() string
+	Description() string
+	Method() string
+
+	Input() Schema
+}
+
 type Schema interface {
 	Name() string
 	In() string
@@ -70,24 +88,60 @@ type Schema interface {
 	Type() string
 
 	ReadOnly() bool
+	WriteOnly() bool
 	Required() bool
 	Nullable() bool
+	Deprecated() bool
 
+	// Enum, Default, Example and Const values are text, with
+	// objects and arrays encoded as JSON
 	Enum() []string
 	EnumDesc() []string
 	Format() string
+	Pattern() string
 	Default() string
 	Example() string
+	Const() string
 
-	Minimum() *int
+	Minimum() *float64
+	Maximum() *float64
+	MultipleOf() *float64
 	MinLength() *int
 	MaxLength() *int
-	//todo: MaxItems
+	MinItems() *int
+	MaxItems() *int
+	UniqueItems() bool
 
+	AllOf() []Schema
 	AnyOf() []Schema
 	OneOf() []Schema
+	Discriminator() *Discriminator
 
 	Items() Schema
+	PrefixItems() []Schema
 	Properties() []Schema
 	Property(name string) (Schema, error)
+	AdditionalProperties() Schema
+}
+
+// Discriminator describes how the variants of a polymorphic
+// schema are told apart by the value of a property
+type Discriminator struct {
+	PropertyName string
+	// Mapping of property values to variant schema names
+	Mapping map[string]string
+}
+
+// Server is a declared location of an API, whose URL
+// may be templated with variables like {region}
+type Server struct {
+	URL         string
+	Description string
+	Variables   map[string]ServerVariable
+}
+
+type ServerVariable struct {
+	Default     string
+	Enum        []string
+	Description string
 }
@@ -0,0 +1,249 @@
+package integra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"tractor.dev/integra/internal/jsonaccess"
+)
+
+// AsyncOperation describes an operation that returns before the work it
+// starts is finished, with a status to poll until it is done or failed.
+// They are configured for a service in meta.yaml:
+//
+//	asyncOperations:
+//	  - match: droplet.create
+//	    status: .droplet.status
+//	    done: [active]
+//	    poll: droplet.get
+//	    params:
+//	      droplet_id: .droplet.id
+//
+// Operations returning a Google style long-running operation, with name,
+// done and error properties, are detected without configuration.
+type AsyncOperation struct {
+	// Match is a glob of resource.operation selectors, like *.create
+	Match string `json:"match"`
+
+	// Status selects the status from a response with Query syntax
+	Status string `json:"status"`
+
+	// Done and Failed are the statuses that finish the operation
+	Done   []string `json:"done"`
+	Failed []string `json:"failed"`
+
+	// Error selects an error from a response, which fails the operation if present
+	Error string `json:"error"`
+
+	// Poll is the resource.operation selector polled for the status
+	Poll string `json:"poll"`
+
+	// Params select the input of the poll operation from the first response
+	Params map[string]string `json:"params"`
+}
+
+// AsyncState is the state of an async operation from a response
+type AsyncState struct {
+	Status string
+	Done   bool
+	Failed bool
+	Error  any
+}
+
+// AsyncOperationFor returns how to wait for an operation given its
+// response, or nil if the operation finishes with its response
+func AsyncOperationFor(op Operation, response any) *AsyncOperation {
+	s := op.Resource().Service()
+	selector := OperationSelector(op)
+	var configured []AsyncOperation
+	if raw := s.Meta().Get("asyncOperations").Data(); raw != nil {
+		b, _ := json.Marshal(raw)
+		json.Unmarshal(b, &configured)
+	}
+	for _, a := range configured {
+		if a.Match != "" && !MatchSelector(s.Name()+"."+a.Match, selector) {
+			continue
+		}
+		if _, ok := queryResponse(response, a.Status); ok {
+			return &a
+		}
+	}
+	return detectAsyncOperation(op, response)
+}
+
+// detectAsyncOperation finds Google style long-running operations, where
+// the output has name and done properties and the service has an
+// operation resource to get them
+func detectAsyncOperation(op Operation, response any) *AsyncOperation {
+	out := op.Output()
+	if out == nil {
+		return nil
+	}
+	var hasName, hasDone bool
+	for _, prop := range out.Properties() {
+		switch prop.Name() {
+		case "name":
+			hasName = true
+		case "done":
+			hasDone = prop.Type() == "boolean"
+		}
+	}
+	if !hasName || !hasDone {
+		return nil
+	}
+	if _, ok := queryResponse(response, ".name"); !ok {
+		return nil
+	}
+	for _, r := range op.Resource().Service().Resources() {
+		if !strings.HasSuffix(strings.ToLower(r.Name()), "operation") {
+			continue
+		}
+		get, err := r.Operation("get")
+		if err != nil {
+			continue
+		}
+		required := RequiredParameters(get)
+		if len(required) != 1 {
+			continue
+		}
+		return &AsyncOperation{
+			Status: ".done",
+			Done:   []string{"true"},
+			Error:  ".error",
+			Poll:   r.Name() + ".get",
+			Params: map[string]string{required[0].Name(): ".name"},
+		}
+	}
+	return nil
+}
+
+func queryResponse(response any, path string) (any, bool) {
+	if path == "" {
+		return nil, false
+	}
+	v, err := jsonaccess.New(response).Query(path)
+	if err != nil || v == nil || v.Data() == nil {
+		return nil, false
+	}
+	return v.Data(), true
+}
+
+// State returns the state of the operation from a response
+func (a *AsyncOperation) State(response any) AsyncState {
+	var state AsyncState
+	if v, ok := queryResponse(response, a.Status); ok {
+		state.Status = fmt.Sprint(v)
+	}
+	if v, ok := queryResponse(response, a.Error); ok {
+		state.Error = v
+		state.Failed = true
+	}
+	state.Done = slices.Contains(a.Done, state.Status)
+	if slices.Contains(a.Failed, state.Status) {
+		state.Failed = true
+	}
+	return state
+}
+
+// PollOperation returns the operation to poll and its input
+// selected from the first response
+func (a *AsyncOperation) PollOperation(op Operation, response any) (Operation, map[string]any, error) {
+	s := op.Resource().Service()
+	sel, err := ParseSelector(s.Name() + "." + a.Poll)
+	if err != nil {
+		return nil, nil, err
+	}
+	poll, err := sel.ResolveOperation(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	in := map[string]any{}
+	for name, path := range a.Params {
+		v, ok := queryResponse(response, path)
+		if !ok {
+			return nil, nil, fmt.Errorf("response has no %s for %s", path, name)
+		}
+		in[name] = v
+	}
+	return poll, in, nil
+}
+
+// AsyncWait configures waiting for an async operation
+type AsyncWait struct {
+	// Interval between polls, 5 seconds if zero
+	Interval time.Duration
+
+	// Do performs the poll operation, see DoFunc
+	Do DoFunc
+
+	// Progress is called with the state after each poll, if set
+	Progress func(state AsyncState)
+}
+
+// AsyncError is returned when an async operation fails
+type AsyncError struct {
+	Selector string
+	State    AsyncState
+}
+
+func (e *AsyncError) Error() string {
+	if e.State.Error != nil {
+		b, _ := json.Marshal(e.State.Error)
+		return fmt.Sprintf("%s failed: %s", e.Selector, b)
+	}
+	return fmt.Sprintf("%s failed with status %s", e.Selector, e.State.Status)
+}
+
+// Wait polls an async operation started by op with the given response
+// until it is done, it fails or ctx is done. It returns the last
+// response and the operation polled.
+func (a *AsyncOperation) Wait(ctx context.Context, op Operation, response any, w AsyncWait) (Operation, any, error) {
+	state := a.State(response)
+	if state.Failed {
+		return op, response, &AsyncError{Selector: OperationSelector(op), State: state}
+	}
+	if state.Done {
+		return op, response, nil
+	}
+	poll, in, err := a.PollOperation(op, response)
+	if err != nil {
+		return op, response, err
+	}
+	interval := w.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	do := w.Do.orDefault()
+	for {
+		select {
+		case <-ctx.Done():
+			return poll, response, fmt.Errorf("waiting for %s: %w", OperationSelector(op), ctx.Err())
+		case <-time.After(interval):
+		}
+		resp, err := do(ctx, poll, in)
+		if err != nil {
+			return poll, response, err
+		}
+		v, err := decodeResponse(resp)
+		if err != nil {
+			return poll, response, err
+		}
+		if v != nil {
+			response = v.Data()
+		}
+		state := a.State(response)
+		if w.Progress != nil {
+			w.Progress(state)
+		}
+		if state.Failed {
+			return poll, response, &AsyncError{Selector: OperationSelector(op), State: state}
+		}
+		if state.Done {
+			return poll, response, nil
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package integra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tractor.dev/integra/internal/jsonaccess"
+)
+
+const testOpenAPIAsync = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+servers:
+  - url: https://api.example.com
+paths:
+  /jobs:
+    post:
+      responses:
+        "202":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/JobResponse"
+  /jobs/{job_id}:
+    parameters:
+      - name: job_id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/JobResponse"
+  /operations/{name}:
+    parameters:
+      - name: name
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Operation"
+  /builds:
+    post:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Operation"
+components:
+  schemas:
+    JobResponse:
+      type: object
+      properties:
+        job:
+          type: object
+          properties:
+            id:
+              type: integer
+            status:
+              type: string
+    Operation:
+      type: object
+      properties:
+        name:
+          type: string
+        done:
+          type: boolean
+        error:
+          type: object
+`
+
+func TestAsyncOperation(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIAsync)
+	s.meta = jsonaccess.New(map[string]any{
+		"latest": "1",
+		"asyncOperations": []any{map[string]any{
+			"match":  "job.*",
+			"status": ".job.status",
+			"done":   []any{"done"},
+			"failed": []any{"failed"},
+			"poll":   "job.get",
+			"params": map[string]any{"job_id": ".job.id"},
+		}},
+	})
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/jobs/7":
+			polls++
+			status := "running"
+			if polls == 3 {
+				status = "done"
+			}
+			json.NewEncoder(w).Encode(map[string]any{"job": map[string]any{"id": 7, "status": status}})
+		case "/operations/builds/1":
+			json.NewEncoder(w).Encode(map[string]any{"name": "builds/1", "done": true, "error": map[string]any{"message": "boom"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	s.SetBaseURL(server.URL)
+
+	create := testOperation(t, s, "job", "create")
+	started := map[string]any{"job": map[string]any{"id": float64(7), "status": "queued"}}
+	async := AsyncOperationFor(create, started)
+	if async == nil {
+		t.Fatal("expected async operation for job.create")
+	}
+	if AsyncOperationFor(create, map[string]any{"other": true}) != nil {
+		t.Error("expected no async operation for response without status")
+	}
+
+	var statuses []string
+	poll, final, err := async.Wait(context.Background(), create, started, AsyncWait{
+		Interval: time.Millisecond,
+		Progress: func(state AsyncState) { statuses = append(statuses, state.Status) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if OperationSelector(poll) != "test.job.get" {
+		t.Errorf("polled %s", OperationSelector(poll))
+	}
+	if len(statuses) != 3 || statuses[2] != "done" {
+		t.Errorf("unexpected progress: %v", statuses)
+	}
+	if got := final.(map[string]any)["job"].(map[string]any)["status"]; got != "done" {
+		t.Errorf("final status = %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	polls = -1000
+	if _, _, err := async.Wait(ctx, create, started, AsyncWait{Interval: time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	build := testOperation(t, s, "build", "create")
+	async = AsyncOperationFor(build, map[string]any{"name": "builds/1", "done": false})
+	if async == nil || async.Poll != "operation.get" {
+		t.Fatalf("expected detected operation polling, got %+v", async)
+	}
+	_, _, err = async.Wait(context.Background(), build, map[string]any{"name": "builds/1", "done": false}, AsyncWait{Interval: time.Millisecond})
+	var aerr *AsyncError
+	if !errors.As(err, &aerr) || !aerr.State.Done {
+		t.Errorf("expected failed operation, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package integra
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AuditEntry records a request that changed something
+type AuditEntry struct {
+	Time        time.Time      `json:"time"`
+	Profile     string         `json:"profile,omitempty"`
+	Selector    string         `json:"selector"`
+	OperationID string         `json:"operationId,omitempty"`
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	Input       map[string]any `json:"input,omitempty"`
+	Status      int            `json:"status,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	DurationMs  int64          `json:"durationMs"`
+}
+
+// AuditPath returns the path of the audit log, which can be set
+// with INTEGRA_AUDIT_LOG or is in the user config directory
+func AuditPath() string {
+	if p := os.Getenv("INTEGRA_AUDIT_LOG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "integra", "audit.jsonl")
+}
+
+// AppendAudit appends an entry to the audit log as a line of JSON
+func AppendAudit(e AuditEntry) error {
+	path := AuditPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// ReadAudit reads all entries of the audit log, oldest first
+func ReadAudit() ([]AuditEntry, error) {
+	f, err := os.Open(AuditPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", AuditPath(), line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+var secretInputWords = []string{"token", "secret", "password", "passwd", "authorization", "apikey", "api_key", "private_key", "credential"}
+
+// isSecretInputName reports whether an input key looks like it holds a secret
+func isSecretInputName(name string) bool {
+	lower := strings.ToLower(name)
+	return slices.ContainsFunc(secretInputWords, func(w string) bool {
+		return strings.Contains(lower, w)
+	})
+}
+
+// RedactInput returns a copy of operation input with values
+// of keys that look like they hold secrets redacted
+func RedactInput(in map[string]any) map[string]any {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		if isSecretInputName(k) {
+			out[k] = Redacted
+			continue
+		}
+		switch vv := v.(type) {
+		case map[string]any:
+			out[k] = RedactInput(vv)
+		case []any:
+			list := make([]any, len(vv))
+			for i, e := range vv {
+				if m, ok := e.(map[string]any); ok {
+					list[i] = RedactInput(m)
+				} else {
+					list[i] = e
+				}
+			}
+			out[k] = list
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
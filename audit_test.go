@@ -0,0 +1,62 @@
+package integra
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRedactInput(t *testing.T) {
+	in := map[string]any{
+		"name":     "web",
+		"password": "hunter2",
+		"config": map[string]any{
+			"webhook_secret": "abc",
+			"url":            "https://example.com",
+		},
+		"keys": []any{map[string]any{"api_key": "xyz"}, "plain"},
+	}
+	expected := map[string]any{
+		"name":     "web",
+		"password": Redacted,
+		"config": map[string]any{
+			"webhook_secret": Redacted,
+			"url":            "https://example.com",
+		},
+		"keys": []any{map[string]any{"api_key": Redacted}, "plain"},
+	}
+	if out := RedactInput(in); !reflect.DeepEqual(out, expected) {
+		t.Errorf("RedactInput() = %v; want %v", out, expected)
+	}
+	if in["password"] != "hunter2" {
+		t.Errorf("input was modified")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Setenv("INTEGRA_AUDIT_LOG", filepath.Join(t.TempDir(), "audit.jsonl"))
+
+	entries, err := ReadAudit()
+	if err != nil || entries != nil {
+		t.Fatalf("expected no entries, got %v %v", entries, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	written := []AuditEntry{
+		{Time: now, Selector: "github.issue.create", Method: "POST", URL: "https://api.github.com/repos/a/b/issues", Status: 201, DurationMs: 120},
+		{Time: now, Selector: "github.issue.delete", Method: "DELETE", Error: "connection refused"},
+	}
+	for _, e := range written {
+		if err := AppendAudit(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err = ReadAudit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, written) {
+		t.Errorf("ReadAudit() = %v; want %v", entries, written)
+	}
+}
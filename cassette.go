@@ -0,0 +1,319 @@
+package integra
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Cassette is an http.RoundTripper that records request/response
+// pairs to a directory, or replays them without touching the network.
+// Credentials and secrets in bodies are scrubbed from recordings, and
+// pairs are keyed by method, URL and a hash of the normalized request body.
+type Cassette struct {
+	Dir string
+
+	// Replay serves recorded responses instead of performing requests
+	Replay bool
+
+	// Transport performs requests when recording. Defaults
+	// to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// NewRecorder returns a cassette that performs requests and records them to dir
+func NewRecorder(dir string) *Cassette {
+	return &Cassette{Dir: dir}
+}
+
+// NewReplayer returns a cassette that serves responses recorded in dir
+func NewReplayer(dir string) *Cassette {
+	return &Cassette{Dir: dir, Replay: true}
+}
+
+type cassetteEntry struct {
+	Request  cassetteMessage `json:"request"`
+	Response cassetteMessage `json:"response"`
+}
+
+type cassetteMessage struct {
+	Method     string      `json:"method,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	Status     int         `json:"status,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"bodyBase64,omitempty"`
+}
+
+func (m *cassetteMessage) setBody(b []byte) {
+	if isPrintable(b) {
+		m.Body = string(b)
+	} else {
+		m.BodyBase64 = base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+func (m *cassetteMessage) body() ([]byte, error) {
+	if m.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(m.BodyBase64)
+	}
+	return []byte(m.Body), nil
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// CassetteKey returns the filename a request is recorded under. The
+// body is scrubbed and normalized first, so equivalent requests with
+// different credentials, JSON key order or multipart boundaries match.
+func CassetteKey(req *http.Request, body []byte) string {
+	u := RedactURL(req.URL)
+	contentType := req.Header.Get("Content-Type")
+	body = normalizeRequestBody(contentType, scrubBody(contentType, body))
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s %s\n%x", req.Method, u, sha256.Sum256(body))))
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(req.URL.Host+req.URL.Path, "_"), "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return fmt.Sprintf("%s_%s_%s.json", req.Method, name, hex.EncodeToString(sum[:6]))
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(c.Dir, CassetteKey(req, body))
+	if c.Replay {
+		return c.replay(req, path)
+	}
+
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	entry := cassetteEntry{
+		Request: cassetteMessage{
+			Method: req.Method,
+			URL:    RedactURL(req.URL),
+			Header: RedactHeader(req.Header),
+		},
+		Response: cassetteMessage{
+			Status: resp.StatusCode,
+			Header: scrubResponseHeader(resp.Header),
+		},
+	}
+	entry.Request.setBody(scrubBody(req.Header.Get("Content-Type"), body))
+	entry.Response.setBody(scrubBody(resp.Header.Get("Content-Type"), respBody))
+
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, path string) (*http.Response, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recording for %s %s in %s", req.Method, RedactURL(req.URL), c.Dir)
+		}
+		return nil, err
+	}
+	var entry cassetteEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	body, err := entry.Response.body()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	header := entry.Response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.Status, http.StatusText(entry.Response.Status)),
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// scrubResponseHeader removes cookies and redacts secrets from a response header
+func scrubResponseHeader(h http.Header) http.Header {
+	out := RedactHeader(h)
+	out.Del("Set-Cookie")
+	return out
+}
+
+// scrubBody redacts fields that look like they hold secrets, like
+// client_secret or access_token, from JSON, form and multipart request
+// and response bodies. Bodies without secrets are kept as they are.
+func scrubBody(contentType string, body []byte) []byte {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case IsJSONMediaType(mediaType):
+		var v any
+		if json.Unmarshal(body, &v) != nil {
+			return body
+		}
+		redacted := redactJSON(v)
+		if reflect.DeepEqual(v, redacted) {
+			return body
+		}
+		b, err := json.Marshal(redacted)
+		if err != nil {
+			return body
+		}
+		return b
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for k := range form {
+			if isSecretInputName(k) {
+				form[k] = []string{Redacted}
+			}
+		}
+		return []byte(form.Encode())
+	case mediaType == "multipart/form-data":
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		if w.SetBoundary(params["boundary"]) != nil {
+			return body
+		}
+		err := eachMultipartPart(params["boundary"], body, func(header textproto.MIMEHeader, content []byte) error {
+			if isSecretInputName(multipartFieldName(header)) {
+				content = []byte(Redacted)
+			}
+			part, err := w.CreatePart(header)
+			if err != nil {
+				return err
+			}
+			_, err = part.Write(content)
+			return err
+		})
+		if err != nil || w.Close() != nil {
+			return body
+		}
+		return buf.Bytes()
+	}
+	return body
+}
+
+// redactJSON redacts secrets in the objects of a JSON value
+func redactJSON(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		return RedactInput(vv)
+	case []any:
+		list := make([]any, len(vv))
+		for i, e := range vv {
+			list[i] = redactJSON(e)
+		}
+		return list
+	}
+	return v
+}
+
+// normalizeRequestBody returns a form of a request body that is the same
+// for equivalent requests. JSON is encoded with sorted keys, and multipart
+// parts are listed without the random boundary between them.
+func normalizeRequestBody(contentType string, body []byte) []byte {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case IsJSONMediaType(mediaType):
+		var v any
+		if json.Unmarshal(body, &v) != nil {
+			return body
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return b
+	case mediaType == "multipart/form-data":
+		var buf bytes.Buffer
+		err := eachMultipartPart(params["boundary"], body, func(header textproto.MIMEHeader, content []byte) error {
+			for _, k := range slices.Sorted(maps.Keys(header)) {
+				fmt.Fprintf(&buf, "%s: %s\n", k, strings.Join(header[k], ", "))
+			}
+			fmt.Fprintf(&buf, "\n%s\n", content)
+			return nil
+		})
+		if err != nil {
+			return body
+		}
+		return buf.Bytes()
+	}
+	return body
+}
+
+// eachMultipartPart calls fn with the header and content of each part of a multipart body
+func eachMultipartPart(boundary string, body []byte, fn func(header textproto.MIMEHeader, content []byte) error) error {
+	if boundary == "" {
+		return fmt.Errorf("missing multipart boundary")
+	}
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := r.NextRawPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		if err := fn(part.Header, content); err != nil {
+			return err
+		}
+	}
+}
+
+func multipartFieldName(header textproto.MIMEHeader) string {
+	_, params, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	return params["name"]
+}
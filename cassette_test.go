@@ -0,0 +1,167 @@
+package integra
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		io.WriteString(w, `{"id": 1, "path": "`+r.URL.Path+`"}`)
+	}))
+	defer server.Close()
+
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	s.SetBaseURL(server.URL)
+	op := testOperation(t, s, "user", "get")
+	dir := t.TempDir()
+
+	do := func(c *Cassette, id string) string {
+		req, err := MakeRequest(op, map[string]any{"id": id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := (&http.Client{Transport: c}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	recorded := do(NewRecorder(dir), "1")
+	if requests != 1 {
+		t.Fatalf("expected 1 request to server, got %d", requests)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 recording, got %d", len(files))
+	}
+	b, _ := os.ReadFile(files[0])
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("recording contains credentials:\n%s", b)
+	}
+
+	server.Close()
+	replayed := do(NewReplayer(dir), "1")
+	if replayed != recorded {
+		t.Errorf("replayed %q; want %q", replayed, recorded)
+	}
+
+	req, _ := MakeRequest(op, map[string]any{"id": "2"})
+	if _, err := (&http.Client{Transport: NewReplayer(dir)}).Do(req); err == nil {
+		t.Errorf("expected error replaying unrecorded request")
+	}
+}
+
+func TestCassetteMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"uploaded": true}`)
+	}))
+	defer server.Close()
+
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	s.SetBaseURL(server.URL)
+	dir := t.TempDir()
+
+	do := func(c *Cassette, op Operation, in map[string]any) string {
+		t.Helper()
+		req, err := MakeRequest(op, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: c}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	upload := testOperation(t, s, "userAvatar", "set")
+	avatar := func() map[string]any {
+		return map[string]any{"id": "1", "caption": "me", "file": strings.NewReader("PNG")}
+	}
+	recorded := do(NewRecorder(dir), upload, avatar())
+
+	update := testOperation(t, s, "user", "update")
+	do(NewRecorder(dir), update, map[string]any{"id": "1", "name": "jeff", "password": "hunter2"})
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, file := range files {
+		b, _ := os.ReadFile(file)
+		if strings.Contains(string(b), "hunter2") {
+			t.Errorf("recording contains secret body field:\n%s", b)
+		}
+	}
+
+	server.Close()
+	// a new multipart boundary is used for every request
+	if replayed := do(NewReplayer(dir), upload, avatar()); replayed != recorded {
+		t.Errorf("replayed %q; want %q", replayed, recorded)
+	}
+	do(NewReplayer(dir), update, map[string]any{"id": "1", "name": "jeff", "password": "other"})
+}
+
+func TestCassetteResponseSecrets(t *testing.T) {
+	responses := map[string]string{
+		"1": `{"id":1,"access_token":"live-token","keys":[{"api_key":"live-key"}]}`,
+		"2": `access_token=live-token&token_type=bearer`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := responses[path.Base(r.URL.Path)]
+		if strings.HasPrefix(body, "{") {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	s.SetBaseURL(server.URL)
+	op := testOperation(t, s, "user", "get")
+	dir := t.TempDir()
+	for id := range responses {
+		req, err := MakeRequest(op, map[string]any{"id": id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := (&http.Client{Transport: NewRecorder(dir)}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(b) != responses[id] {
+			t.Errorf("recording changed the live response: %s", b)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 recordings, got %d", len(files))
+	}
+	for _, file := range files {
+		b, _ := os.ReadFile(file)
+		if strings.Contains(string(b), "live-") {
+			t.Errorf("recording contains response secrets:\n%s", b)
+		}
+	}
+}
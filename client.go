@@ -0,0 +1,352 @@
+package integra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+	"sync"
+
+	"tractor.dev/integra/internal/jsonaccess"
+)
+
+// JSONValue is JSON data returned by a Client, which can
+// be navigated with Get, Keys and Items and converted with ValueAs
+type JSONValue = jsonaccess.Value
+
+// ValueAs converts JSON data returned by a Client to type T
+func ValueAs[T any](v *JSONValue) (T, error) {
+	return jsonaccess.As[T](v)
+}
+
+// DoFunc performs an operation with input, like Client.Do. Types that
+// perform operations with a DoFunc use Client.Do of a client with
+// default settings if it is nil, so credentials come from the
+// environment. Use the Do of a configured Client for its Auth and
+// Middleware.
+type DoFunc func(ctx context.Context, op Operation, in map[string]any) (*http.Response, error)
+
+var defaultClient = NewClient()
+
+// orDefault returns f, or Client.Do of the default client if f is nil
+func (f DoFunc) orDefault() DoFunc {
+	if f == nil {
+		return defaultClient.Do
+	}
+	return f
+}
+
+// Handler performs the request for an operation
+type Handler func(ctx context.Context, op Operation, req *http.Request) (*http.Response, error)
+
+// Middleware wraps a Handler to observe or change requests
+// and responses, or to refuse them by returning an error
+type Middleware func(next Handler) Handler
+
+// AuthProvider adds credentials to requests for a service
+type AuthProvider interface {
+	Authorize(req *http.Request, s Service) error
+}
+
+// AuthFunc is a function used as an AuthProvider
+type AuthFunc func(req *http.Request, s Service) error
+
+func (f AuthFunc) Authorize(req *http.Request, s Service) error {
+	return f(req, s)
+}
+
+// TokenAuth returns an AuthProvider using a bearer token for every service
+func TokenAuth(token string) AuthProvider {
+	return AuthFunc(func(req *http.Request, s Service) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// ResponseError is returned by a Client for responses that aren't successful
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *ResponseError) Error() string {
+	body := strings.TrimSpace(string(e.Body))
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	if body == "" {
+		return e.Status
+	}
+	return fmt.Sprintf("%s: %s", e.Status, body)
+}
+
+// Client performs operations on services by selector. Services are
+// loaded as needed, and requests are built like MakeRequest, using
+// tokens from the environment unless Auth is set.
+//
+//	client := integra.NewClient()
+//	repo, err := client.Call(ctx, "github.repo.get", map[string]any{"owner": "golang", "repo": "go"})
+type Client struct {
+	// HTTPClient performs requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Auth adds credentials to requests, if set
+	Auth AuthProvider
+
+	// Middleware wraps each request, the first being outermost
+	Middleware []Middleware
+
+	mu       sync.Mutex
+	services map[string]Service
+}
+
+// NewClient returns a client with default settings
+func NewClient() *Client {
+	return &Client{}
+}
+
+// Use adds middleware to the client
+func (c *Client) Use(mw ...Middleware) {
+	c.Middleware = append(c.Middleware, mw...)
+}
+
+// AddService makes a service available to the client by its name,
+// for services that are not embedded or have been configured
+func (c *Client) AddService(s Service) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.services == nil {
+		c.services = make(map[string]Service)
+	}
+	c.services[s.Name()] = s
+}
+
+// Service returns a service by name, with an optional @version, loading it if needed
+func (c *Client) Service(name string) (Service, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.services[name]; ok {
+		return s, nil
+	}
+	serviceName, version := SplitSelectorVersion(name)
+	s, err := LoadService(serviceName, version)
+	if err != nil {
+		return nil, err
+	}
+	if c.services == nil {
+		c.services = make(map[string]Service)
+	}
+	c.services[name] = s
+	return s, nil
+}
+
+// Operation returns the operation for a selector like github.repo.get
+func (c *Client) Operation(selector string) (Operation, error) {
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	serviceName := sel.Service
+	if sel.Version != "" {
+		serviceName += "@" + sel.Version
+	}
+	s, err := c.Service(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return sel.ResolveOperation(s)
+}
+
+// Do performs an operation and returns the response
+func (c *Client) Do(ctx context.Context, op Operation, input map[string]any) (*http.Response, error) {
+	req, err := c.request(op, input)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, op, req)
+}
+
+// request builds the request for an operation, leaving
+// credentials to Auth if it is set
+func (c *Client) request(op Operation, input map[string]any) (*http.Request, error) {
+	return makeRequest(op, input, c.Auth == nil)
+}
+
+// send authorizes and performs a request through the middleware
+func (c *Client) send(ctx context.Context, op Operation, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	if c.Auth != nil {
+		if err := c.Auth.Authorize(req, op.Resource().Service()); err != nil {
+			return nil, err
+		}
+	}
+	handler := Handler(func(ctx context.Context, op Operation, req *http.Request) (*http.Response, error) {
+		client := c.HTTPClient
+		if client == nil {
+			client = http.DefaultClient
+		}
+		return client.Do(req)
+	})
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		handler = c.Middleware[i](handler)
+	}
+	return handler(ctx, op, req)
+}
+
+// Call performs the operation for a selector and returns the decoded
+// JSON response. Responses without content return nil, and responses
+// that aren't successful return a ResponseError.
+func (c *Client) Call(ctx context.Context, selector string, input map[string]any) (*JSONValue, error) {
+	op, err := c.Operation(selector)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(ctx, op, input)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponse(resp)
+}
+
+func decodeResponse(resp *http.Response) (*JSONValue, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode > 299 {
+		return nil, &ResponseError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return jsonaccess.New(data), nil
+}
+
+// List performs a list operation and iterates over the items of
+// each page, following next page links and tokens until there are
+// no more pages or the loop stops
+func (c *Client) List(ctx context.Context, selector string, input map[string]any) iter.Seq2[*JSONValue, error] {
+	return func(yield func(*JSONValue, error) bool) {
+		op, err := c.Operation(selector)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if input == nil {
+			input = map[string]any{}
+		}
+		req, err := c.request(op, input)
+		for {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if req == nil {
+				return
+			}
+			var resp *http.Response
+			resp, err = c.send(ctx, op, req)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			link := resp.Header.Get("Link")
+			var page *JSONValue
+			page, err = decodeResponse(resp)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if page == nil {
+				return
+			}
+			for _, item := range listItems(op, page) {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			req, err = c.nextPageRequest(op, input, req, link, page)
+		}
+	}
+}
+
+// listItems returns the items of a page of a list operation
+func listItems(op Operation, page *JSONValue) []*JSONValue {
+	if items := page.Items(); items != nil {
+		return items
+	}
+	resp, out := op.Response(), op.Output()
+	if resp == nil || out == nil || resp.Name() == out.Name() {
+		return nil
+	}
+	return page.Get(out.Name()).Items()
+}
+
+// nextPageRequest returns a request for the page after the given one
+// using a Link header, a next page URL in the body, or a page token.
+// It returns nil when there are no more pages. Next page URLs must be
+// on the same scheme and host as the previous page, since the request
+// is sent with the same credentials.
+func (c *Client) nextPageRequest(op Operation, input map[string]any, prev *http.Request, link string, page *JSONValue) (*http.Request, error) {
+	next := linkHeaderNext(link)
+	if next == "" {
+		// digitalocean style, then spotify style
+		next = jsonaccess.AsOrZero[string](page.Get("links", "pages", "next"))
+	}
+	if next == "" {
+		next = jsonaccess.AsOrZero[string](page.Get("next"))
+	}
+	if next == "" {
+		if out := op.Output(); out != nil {
+			next = jsonaccess.AsOrZero[string](page.Get(out.Name(), "next"))
+		}
+	}
+	if strings.HasPrefix(next, "http") {
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		if req.URL.Scheme != prev.URL.Scheme || req.URL.Host != prev.URL.Host {
+			return nil, fmt.Errorf("next page %s is not on %s://%s", RedactURL(req.URL), prev.URL.Scheme, prev.URL.Host)
+		}
+		req.Header = prev.Header.Clone()
+		return req, nil
+	}
+
+	// google style
+	if token := jsonaccess.AsOrZero[string](page.Get("nextPageToken")); token != "" {
+		in := make(map[string]any, len(input)+1)
+		for k, v := range input {
+			in[k] = v
+		}
+		in["pageToken"] = token
+		return c.request(op, in)
+	}
+	return nil, nil
+}
+
+// linkHeaderNext returns the URL with rel="next" in a Link header
+func linkHeaderNext(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package integra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient(t *testing.T) {
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/pets":
+			if r.URL.Query().Get("page") == "2" {
+				io.WriteString(w, `{"pets": [{"id": 3}], "total": 3}`)
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/pets?page=2>; rel="next", <http://%s/pets?page=2>; rel="last"`, r.Host, r.Host))
+			io.WriteString(w, `{"pets": [{"id": 1}, {"id": 2}], "total": 3}`)
+		case "/pets/1":
+			io.WriteString(w, `{"id": 1, "name": "Fido"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	s.SetBaseURL(server.URL)
+
+	var seen []string
+	client := NewClient()
+	client.AddService(s)
+	client.Auth = TokenAuth("secret")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, op Operation, req *http.Request) (*http.Response, error) {
+			seen = append(seen, OperationSelector(op))
+			return next(ctx, op, req)
+		}
+	})
+	ctx := context.Background()
+
+	pet, err := client.Call(ctx, "test.pet.get", map[string]any{"pet_id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := ValueAs[string](pet.Get("name")); name != "Fido" {
+		t.Errorf("name = %q; want Fido", name)
+	}
+
+	_, err = client.Call(ctx, "test.pet.get", map[string]any{"pet_id": 2})
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != 404 {
+		t.Errorf("expected 404 ResponseError, got %v", err)
+	}
+
+	var ids []int
+	for item, err := range client.List(ctx, "test.pet.list", nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := ValueAs[int](item.Get("id"))
+		ids = append(ids, id)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("listed ids = %v; want [1 2 3]", ids)
+	}
+
+	for _, a := range auth {
+		if a != "Bearer secret" {
+			t.Errorf("request authorization = %q", a)
+		}
+	}
+	if len(seen) != 4 || seen[0] != "test.pet.get" || seen[3] != "test.pet.list" {
+		t.Errorf("middleware saw %v", seen)
+	}
+
+	if _, err := client.Call(ctx, "test.pet", nil); err == nil {
+		t.Errorf("expected error for incomplete selector")
+	}
+}
+
+func TestClientAuthSkipsEnvToken(t *testing.T) {
+	t.Setenv("TEST_TOKEN", "env-token")
+	var auth, key []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		key = append(key, r.URL.Query().Get("key"))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": 1}`)
+	}))
+	defer server.Close()
+
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	s.SetBaseURL(server.URL)
+	client := NewClient()
+	client.AddService(s)
+	ctx := context.Background()
+	if _, err := client.Call(ctx, "test.pet.get", map[string]any{"pet_id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	client.Auth = AuthFunc(func(req *http.Request, s Service) error {
+		q := req.URL.Query()
+		q.Set("key", "api-key")
+		req.URL.RawQuery = q.Encode()
+		return nil
+	})
+	if _, err := client.Call(ctx, "test.pet.get", map[string]any{"pet_id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(auth) != "[Bearer env-token ]" || fmt.Sprint(key) != "[ api-key]" {
+		t.Errorf("authorization = %q, key = %q; want env token only without Auth", auth, key)
+	}
+}
+
+func TestNextPageRequestHost(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	op := testOperation(t, s, "pet", "list")
+	prev, err := MakeRequest(op, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev.Header.Set("Authorization", "Bearer secret")
+
+	req, err := NewClient().nextPageRequest(op, nil, prev, `<https://api.example.com/v2/pets?page=2>; rel="next"`, nil)
+	if err != nil || req.Header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("same host next page = %v, %v", req, err)
+	}
+	for _, link := range []string{
+		`<https://attacker.example/pets?page=2>; rel="next"`,
+		`<http://api.example.com/v2/pets?page=2>; rel="next"`,
+	} {
+		if req, err := NewClient().nextPageRequest(op, nil, prev, link, nil); err == nil {
+			t.Errorf("expected error following %s, got %v", link, req.URL)
+		}
+	}
+}
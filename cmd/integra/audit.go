@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func auditCmd() *cli.Command {
+	var (
+		selector string
+		since    string
+		profile  string
+		failed   bool
+		asJSON   bool
+	)
+	cmd := &cli.Command{
+		Usage: "audit",
+		Short: "show the log of requests that changed something",
+		Args:  cli.ExactArgs(0),
+		Run: func(ctx *cli.Context, args []string) {
+			var after time.Time
+			if since != "" {
+				var err error
+				after, err = parseSince(since)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			entries, err := integra.ReadAudit()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			defer w.Flush()
+			if !asJSON {
+				fmt.Fprintln(w, "TIME\tSELECTOR\tMETHOD\tSTATUS\tDURATION\tPROFILE\tURL")
+			}
+			for _, e := range entries {
+				if selector != "" && !integra.MatchSelector(selector, e.Selector) {
+					continue
+				}
+				if profile != "" && e.Profile != profile {
+					continue
+				}
+				if e.Time.Before(after) {
+					continue
+				}
+				if failed && e.Error == "" && e.Status < 300 {
+					continue
+				}
+				if asJSON {
+					b, _ := json.Marshal(e)
+					fmt.Println(string(b))
+					continue
+				}
+				status := strconv.Itoa(e.Status)
+				if e.Error != "" {
+					status = "error: " + shortText(e.Error)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%dms\t%s\t%s\n",
+					e.Time.Local().Format(time.DateTime),
+					e.Selector,
+					e.Method,
+					status,
+					e.DurationMs,
+					e.Profile,
+					e.URL,
+				)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&selector, "selector", "", "only show operations matching selector glob (ex: github.*.delete)")
+	cmd.Flags().StringVar(&since, "since", "", "only show entries since a duration ago (ex: 24h) or date (ex: 2024-01-31)")
+	cmd.Flags().StringVar(&profile, "profile", "", "only show entries made with profile")
+	cmd.Flags().BoolVar(&failed, "failed", false, "only show failed requests")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print entries as JSON lines")
+	return cmd
+}
+
+// parseSince parses a duration before now or a date
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since, expected duration or date: %s", strings.TrimSpace(s))
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 	"strings"
@@ -15,56 +18,92 @@ import (
 )
 
 func callCmd() *cli.Command {
+	var (
+		outputPath  string
+		dataSpec    string
+		profileName string
+		servers     serverFlags
+		export      exportFlags
+		shape       shapeFlags
+		cassette    cassetteFlags
+		policy      policyFlags
+		yes         bool
+		wait        waitFlags
+	)
 	cmd := &cli.Command{
 		Usage: "call <selector>",
 		Short: "perform an operation on a service resource",
 		Args:  cli.MinArgs(1),
 		Run: func(ctx *cli.Context, args []string) {
-			selector, version := integra.SplitSelectorVersion(args[0])
-			sel := strings.Split(selector, ".")
+			if err := shape.validate(); err != nil {
+				log.Fatal(err)
+			}
+			sel, err := integra.ParseSelector(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
 
-			s, err := integra.LoadService(sel[0], version)
+			s, err := sel.LoadService()
 			if err != nil {
 				log.Fatal(err)
 			}
+			if err := cassette.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := policy.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := useProfile(s, profileName); err != nil {
+				log.Fatal(err)
+			}
+			if err := servers.apply(s); err != nil {
+				log.Fatal(err)
+			}
 
-			if len(sel) == 1 {
-				fmt.Printf("missing resource in selector. use `integra describe %s` to list resources.\n", sel[0])
+			if sel.Resource == "" {
+				fmt.Printf("missing resource in selector. use `integra describe %s` to list resources.\n", args[0])
 				os.Exit(1)
 				return
 			}
 
-			r, err := s.Resource(sel[1])
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			if len(sel) == 2 {
+			if sel.Operation == "" {
 				fmt.Printf("missing operation in selector. use `integra describe %s` to list operations.\n", args[0])
 				os.Exit(1)
 				return
 			}
 
-			op, err := r.Operation(sel[2])
+			op, err := sel.ResolveOperation(s)
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			data := map[string]any{}
+			data, err := readInput(dataSpec)
+			if err != nil {
+				log.Fatal(err)
+			}
 			if len(args) > 1 {
 				parsed, err := clon.Parse(args[1:])
 				if err != nil {
 					log.Fatal(err)
 				}
-				data = parsed.(map[string]any)
+				data = integra.MergeInput(data, parsed.(map[string]any))
+			}
+			if err := readFileInputs(data); err != nil {
+				log.Fatal(err)
 			}
 
-			req, err := integra.MakeRequest(op, data)
-			if err != nil {
+			if export.enabled() {
+				if err := export.print(op, data); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+
+			if err := confirmDestructive(op, yes, askStdin); err != nil {
 				log.Fatal(err)
 			}
 
-			resp, err := http.DefaultClient.Do(req)
+			resp, err := doRequest(op, data)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -77,23 +116,215 @@ func callCmd() *cli.Command {
 				return
 			}
 
-			var reply any
-			dec := json.NewDecoder(resp.Body)
-			if err := dec.Decode(&reply); err != nil {
-				log.Fatal(err)
+			if wait.enabled {
+				if err := wait.print(op, resp, outputPath, shape); err != nil {
+					log.Fatal(err)
+				}
+				return
 			}
 
-			b, err := json.MarshalIndent(reply, "", "  ")
-			if err != nil {
+			if err := printResponse(resp, outputPath, func(w io.Writer, v any) error {
+				return shape.print(w, op, v)
+			}); err != nil {
 				log.Fatal(err)
 			}
-			fmt.Println(string(b))
 
 		},
 	}
+	cmd.Flags().StringVar(&dataSpec, "data", "", "read input from JSON or YAML (@file, - for stdin, or inline)")
+	cmd.Flags().StringVar(&outputPath, "output", "", "write response body to file (- for stdout)")
+	cmd.Flags().StringVar(&profileName, "profile", "", "use named profile for credentials and defaults")
+	servers.register(cmd)
+	cassette.register(cmd)
+	policy.register(cmd)
+	cmd.Flags().BoolVar(&yes, "yes", false, "perform delete operations without asking")
+	wait.register(cmd)
+	export.register(cmd)
+	shape.register(cmd)
 	return cmd
 }
 
+// printResponse writes a response body based on its content type. JSON
+// values are written with printValue, text is written as-is, JSON lines
+// and event streams are written as they arrive, and binary data must go
+// to a file or a pipe.
+func printResponse(resp *http.Response, outputPath string, printValue func(io.Writer, any) error) error {
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	out := os.Stdout
+	if outputPath != "" && outputPath != "-" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case isJSONLinesMediaType(mediaType):
+		return printJSONLines(out, resp.Body, printValue)
+
+	case mediaType == "text/event-stream":
+		return printEvents(out, resp.Body)
+
+	case mediaType == "" || integra.IsJSONMediaType(mediaType):
+		var reply any
+		dec := json.NewDecoder(resp.Body)
+		if err := dec.Decode(&reply); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		return printValue(out, reply)
+
+	case isTextMediaType(mediaType):
+		_, err := io.Copy(out, resp.Body)
+		return err
+
+	default:
+		if outputPath == "" && isTerminal(out) {
+			return fmt.Errorf("response is binary (%s), use --output <file> to save it", mediaType)
+		}
+		n, err := io.Copy(out, resp.Body)
+		if err != nil {
+			return err
+		}
+		if out != os.Stdout {
+			fmt.Fprintf(os.Stderr, "wrote %d bytes to %s\n", n, outputPath)
+		}
+		return nil
+	}
+}
+
+// printOutput writes with print to a file, or stdout if
+// outputPath is empty or -
+func printOutput(outputPath string, print func(io.Writer) error) error {
+	if outputPath == "" || outputPath == "-" {
+		return print(os.Stdout)
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := print(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printJSON(w io.Writer, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// printJSONLines prints each value of a newline delimited JSON stream
+func printJSONLines(w io.Writer, r io.Reader, printValue func(io.Writer, any) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(line, &v); err != nil {
+			return err
+		}
+		if err := printValue(w, v); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// printEvents prints the data of each server-sent event, pretty printing
+// data that is JSON and prefixing events that have a type
+func printEvents(w io.Writer, r io.Reader) error {
+	var event string
+	var data []string
+	flush := func() error {
+		defer func() {
+			event = ""
+			data = nil
+		}()
+		if len(data) == 0 {
+			return nil
+		}
+		if event != "" {
+			fmt.Fprintf(w, "%s: ", event)
+		}
+		payload := strings.Join(data, "\n")
+		var v any
+		if json.Unmarshal([]byte(payload), &v) == nil {
+			return printJSON(w, v)
+		}
+		_, err := fmt.Fprintln(w, payload)
+		return err
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			// blank line ends an event, otherwise it's a comment
+			if line == "" {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func isJSONLinesMediaType(mediaType string) bool {
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl",
+		"application/x-jsonlines", "application/stream+json":
+		return true
+	}
+	return false
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/xml", "application/yaml", "application/x-yaml", "application/javascript":
+		return true
+	}
+	return false
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func requiredParams(op integra.Operation) (required []string) {
 	// from params
 	for _, p := range op.Parameters() {
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+// commandNames are the names of visible subcommands, for completion
+var commandNames []string
+
+// commandFlags are the flags of subcommands by name, for completion
+var commandFlags = map[string]*flag.FlagSet{}
+
+// selectorCommands are subcommands taking a selector as their first
+// argument, and whether they take data arguments after it
+var selectorCommands = map[string]bool{
+	"call":     true,
+	"describe": false,
+	"fetch":    false,
+	"listen":   false,
+	"mock":     false,
+	"mcp":      false,
+	"serve":    false,
+	"shell":    false,
+}
+
+func completionCmd() *cli.Command {
+	cmd := &cli.Command{
+		Usage: "completion <shell>",
+		Short: "print a shell completion script for bash, zsh or fish",
+		Long: `Prints a script completing subcommands, selectors and parameter names.
+Load it from your shell profile, for example:
+
+  source <(integra completion bash)    # ~/.bashrc
+  source <(integra completion zsh)     # ~/.zshrc
+  integra completion fish | source     # ~/.config/fish/config.fish`,
+		Args: cli.ExactArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			switch args[0] {
+			case "bash":
+				fmt.Print(bashCompletion)
+			case "zsh":
+				fmt.Print(zshCompletion)
+			case "fish":
+				fmt.Print(fishCompletion)
+			default:
+				fmt.Printf("unsupported shell: %s (use bash, zsh or fish)\n", args[0])
+				os.Exit(1)
+			}
+		},
+	}
+	return cmd
+}
+
+func completeCmd() *cli.Command {
+	cmd := &cli.Command{
+		Usage:  "__complete",
+		Short:  "print completions for the words of a command line",
+		Hidden: true,
+		Run: func(ctx *cli.Context, args []string) {
+			if len(args) > 0 && args[0] == "--" {
+				args = args[1:]
+			}
+			for _, c := range complete(args) {
+				fmt.Println(c)
+			}
+		},
+	}
+	return cmd
+}
+
+// complete returns candidates for the last of the words following
+// integra on a command line, which is empty when starting a new word
+func complete(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	current := words[len(words)-1]
+	if len(words) == 1 {
+		return withPrefix(commandNames, current)
+	}
+	if strings.HasPrefix(current, "-") {
+		return nil
+	}
+
+	var (
+		positional []string
+		flagValue  bool
+	)
+	flags := commandFlags[words[0]]
+	for _, w := range words[1 : len(words)-1] {
+		switch {
+		case flagValue:
+			flagValue = false
+		case strings.HasPrefix(w, "-"):
+			flagValue = takesValue(flags, w)
+		default:
+			positional = append(positional, w)
+		}
+	}
+	if flagValue {
+		// the current word is the value of a flag
+		return nil
+	}
+	command := words[0]
+	if command == "generate" {
+		if len(positional) == 0 {
+			return withPrefix([]string{"sample"}, current)
+		}
+		command, positional = "generate "+positional[0], positional[1:]
+	}
+
+	takesData, ok := selectorCommands[command]
+	switch {
+	case command == "generate sample" && len(positional) == 0:
+		return completeSelector(current)
+	case command == "mcp" || command == "serve":
+		return completeServices(current, "")
+	case !ok:
+		return nil
+	case len(positional) == 0:
+		if command == "fetch" || command == "listen" || command == "mock" || command == "shell" {
+			return completeServices(current, "")
+		}
+		return completeSelector(current)
+	case takesData:
+		return completeParameters(positional[0], current)
+	}
+	return nil
+}
+
+// takesValue reports whether a flag word like --profile is
+// followed by its value as the next word
+func takesValue(flags *flag.FlagSet, word string) bool {
+	if flags == nil || strings.Contains(word, "=") {
+		return false
+	}
+	f := flags.Lookup(strings.TrimLeft(word, "-"))
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
+// completeServices completes service names, followed by suffix
+func completeServices(current, suffix string) (candidates []string) {
+	for _, name := range withPrefix(integra.AvailableServices(), current) {
+		candidates = append(candidates, name+suffix)
+	}
+	return candidates
+}
+
+// completeSelector completes a selector one part at a time,
+// loading the service model once the service is typed
+func completeSelector(current string) (candidates []string) {
+	parts := strings.Split(current, ".")
+	if len(parts) == 1 {
+		return completeServices(current, ".")
+	}
+	serviceName, version := integra.SplitSelectorVersion(parts[0])
+	s, err := integra.LoadService(serviceName, version)
+	if err != nil {
+		return nil
+	}
+	if len(parts) == 2 {
+		var names []string
+		for _, r := range s.Resources() {
+			names = append(names, r.Name())
+		}
+		for _, name := range withPrefix(names, parts[1]) {
+			candidates = append(candidates, parts[0]+"."+name+".")
+		}
+		return candidates
+	}
+	if len(parts) == 3 {
+		r, err := s.Resource(parts[1])
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, op := range r.Operations() {
+			names = append(names, op.Name())
+		}
+		for _, name := range withPrefix(names, parts[2]) {
+			candidates = append(candidates, strings.Join(parts[:2], ".")+"."+name)
+		}
+	}
+	return candidates
+}
+
+// completeParameters completes key= arguments for the
+// parameters and input properties of the selected operation
+func completeParameters(selector, current string) (candidates []string) {
+	if strings.Contains(current, "=") {
+		return nil
+	}
+	sel, err := integra.ParseSelector(selector)
+	if err != nil {
+		return nil
+	}
+	s, err := sel.LoadService()
+	if err != nil {
+		return nil
+	}
+	op, err := sel.ResolveOperation(s)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, param := range op.Parameters() {
+		names = append(names, param.Name())
+	}
+	if input := op.Input(); input != nil {
+		for _, prop := range input.Properties() {
+			if !slices.Contains(names, prop.Name()) {
+				names = append(names, prop.Name())
+			}
+		}
+	}
+	for _, name := range withPrefix(names, current) {
+		candidates = append(candidates, name+"=")
+	}
+	return candidates
+}
+
+func withPrefix(names []string, prefix string) (matches []string) {
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	slices.Sort(matches)
+	return matches
+}
+
+const bashCompletion = `# bash completion for integra
+_integra() {
+	local IFS=$'\n'
+	COMPREPLY=($(integra __complete -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+	if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == *[.=] ]]; then
+		compopt -o nospace
+	fi
+}
+complete -o default -F _integra integra
+`
+
+const zshCompletion = `#compdef integra
+# zsh completion for integra
+_integra() {
+	local -a candidates
+	candidates=("${(@f)$(integra __complete -- "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+	candidates=(${candidates:#})
+	if (( ${#candidates} == 0 )); then
+		_files
+		return
+	fi
+	compadd -S '' -Q -- "${candidates[@]:#*[^.=]}"
+	compadd -Q -- "${(M)candidates[@]:#*[^.=]}"
+}
+if [[ "$funcstack[1]" = "_integra" ]]; then
+	_integra "$@"
+else
+	compdef _integra integra
+fi
+`
+
+const fishCompletion = `# fish completion for integra
+function __integra_complete
+	set -l words (commandline -opc)
+	set -e words[1]
+	set -l cur (commandline -ct)
+	integra __complete -- $words "$cur" 2>/dev/null
+end
+complete -c integra -f -a '(__integra_complete)'
+`
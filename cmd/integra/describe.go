@@ -36,52 +36,51 @@ func describeCmd() *cli.Command {
 				return
 			}
 
-			selector, version := integra.SplitSelectorVersion(args[0])
-			sel := strings.Split(selector, ".")
+			sel, err := integra.ParseSelector(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
 
-			s, err := integra.LoadService(sel[0], version)
+			s, err := sel.LoadService()
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			if len(sel) == 1 {
+			if sel.IsGlob() {
+				describeMatchingOperations(s, sel)
+				return
+			}
+
+			if sel.Resource == "" {
 				describeService(s)
 				return
 			}
 
-			r, err := s.Resource(sel[1])
+			r, err := sel.ResolveResource(s)
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			if len(sel) == 2 {
+			if sel.Operation == "" {
 				describeResource(r)
 				return
 			}
 
-			// TODO: select into resource properties
-			op, err := r.Operation(sel[2])
+			op, err := sel.ResolveOperation(s)
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			if len(sel) == 3 {
+			if len(sel.Schema) == 0 {
 				describeOperation(op)
 				return
 			}
 
-			// opSelector := sel[3]
-			// if opSelector == "in" {
-			// 	describeOperationParams(op)
-			// 	return
-			// }
-			// if opSelector == "out" {
-			// 	describeOperationResponse(op)
-			// 	return
-			// }
-
-			fmt.Println("invalid selector")
-			os.Exit(1)
+			schema, err := sel.ResolveSchema(op)
+			if err != nil {
+				log.Fatal(err)
+			}
+			describeSchema(schema)
 		},
 	}
 	cmd.Flags().BoolVar(&accountData, "account", false, "only account data")
@@ -109,6 +108,22 @@ func describeServiceInfo(s integra.Service) {
 	fmt.Fprintf(w, "Categories:\t%s\n", strings.Join(s.Categories(), ", "))
 	fmt.Fprintf(w, "Security:\t%s\n", strings.Join(s.Security(), ", "))
 	fmt.Fprintf(w, "Base URL:\t%s\n", s.BaseURL())
+	for idx, server := range s.Servers() {
+		fmt.Fprintf(w, "Server %d:\t%s\t%s\n", idx, server.URL, server.Description)
+		var names []string
+		for name := range server.Variables {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			v := server.Variables[name]
+			desc := v.Default
+			if len(v.Enum) > 0 {
+				desc = fmt.Sprintf("%s (%s)", v.Default, strings.Join(v.Enum, ", "))
+			}
+			fmt.Fprintf(w, "  {%s}:\t%s\t\n", name, desc)
+		}
+	}
 	fmt.Fprintf(w, "Docs URL:\t%s\n", s.DocsURL())
 }
 
@@ -161,6 +176,15 @@ func describeServiceResources(s integra.Service) {
 	// }
 }
 
+func describeServiceWebhooks(webhooks []integra.Webhook) {
+	w := describeTabWriter()
+	defer w.Flush()
+	for _, wh := range webhooks {
+		fmt.Fprintf(w, "%s\t%s\n", wh.Name(), shortText(wh.Description()))
+	}
+	fmt.Fprintln(w)
+}
+
 func describeService(service integra.Service) {
 	if showInfo {
 		// only show info
@@ -181,6 +205,11 @@ func describeService(service integra.Service) {
 	fmt.Printf("=== SERVICE RESOURCES\n")
 	describeServiceResources(service)
 
+	if webhooks := service.Webhooks(); len(webhooks) > 0 {
+		fmt.Printf("=== SERVICE WEBHOOKS\n")
+		describeServiceWebhooks(webhooks)
+	}
+
 }
 
 func describeResourceOperations(r integra.Resource) {
@@ -324,6 +353,12 @@ func describeOperation(op integra.Operation) {
 		fmt.Printf("=== OPERATION INPUT\n")
 		fmt.Printf("%s:\n", input.Type())
 		describePropSummary(input.Properties(), "  ", true)
+		describeVariants(input, true)
+	}
+
+	if responses := op.Responses(); len(responses) > 1 {
+		fmt.Printf("=== OPERATION RESPONSES\n")
+		describeResponses(responses)
 	}
 
 	resp := op.Response()
@@ -344,6 +379,76 @@ func describeOperation(op integra.Operation) {
 			fmt.Printf("%s:\n", output.Type())
 		}
 		describePropSummary(output.Properties(), "  ", false)
+		describeVariants(output, false)
+	}
+}
+
+func describeMatchingOperations(s integra.Service, sel integra.Selector) {
+	w := describeTabWriter()
+	defer w.Flush()
+	for _, op := range sel.Operations(s) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", integra.OperationSelector(op), strings.ToUpper(op.Method()), shortText(op.Description()))
+	}
+}
+
+func describeSchema(schema integra.Schema) {
+	if schema.Type() == "array" && schema.Items() != nil {
+		fmt.Printf("%s of %s:\n", schema.Type(), schema.Items().Type())
+		schema = schema.Items()
+	} else {
+		fmt.Printf("%s:\n", schema.Type())
+	}
+	if d := schema.Description(); d != "" {
+		fmt.Printf("  %s\n\n", shortText(d))
+	}
+	describePropSummary(schema.Properties(), "  ", false)
+	describeVariants(schema, false)
+}
+
+func describeResponses(responses map[string]map[string]integra.Schema) {
+	w := describeTabWriter()
+	defer w.Flush()
+	var statuses []string
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	slices.Sort(statuses)
+	for _, status := range statuses {
+		content := responses[status]
+		if len(content) == 0 {
+			fmt.Fprintf(w, "%s\t(no content)\t\n", status)
+			continue
+		}
+		var mediaTypes []string
+		for mediaType := range content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		slices.Sort(mediaTypes)
+		for _, mediaType := range mediaTypes {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", status, mediaType, content[mediaType].Type())
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func describeVariants(schema integra.Schema, showOptional bool) {
+	kind := "one of"
+	variants := schema.OneOf()
+	if len(variants) == 0 {
+		kind = "any of"
+		variants = schema.AnyOf()
+	}
+	if len(variants) == 0 {
+		return
+	}
+	if d := schema.Discriminator(); d != nil {
+		fmt.Printf("%s (by %s):\n", kind, d.PropertyName)
+	} else {
+		fmt.Printf("%s:\n", kind)
+	}
+	for _, v := range variants {
+		fmt.Printf("  %s %s:\n", v.Name(), v.Type())
+		describePropSummary(v.Properties(), "    ", showOptional)
 	}
 }
 
@@ -21,20 +21,36 @@ import (
 )
 
 func fetchCmd() *cli.Command {
+	var (
+		profileName string
+		servers     serverFlags
+		cassette    cassetteFlags
+	)
 	cmd := &cli.Command{
 		Usage: "fetch <service> <dir>",
 		Short: "",
 		Args:  cli.MinArgs(2),
 		Run: func(ctx *cli.Context, args []string) {
-			selector, version := integra.SplitSelectorVersion(args[0])
-			sel := strings.Split(selector, ".")
+			sel, err := integra.ParseSelector(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
 
-			s, err := integra.LoadService(sel[0], version)
+			s, err := sel.LoadService()
 			if err != nil {
 				log.Fatal(err)
 			}
+			if err := cassette.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := useProfile(s, profileName); err != nil {
+				log.Fatal(err)
+			}
+			if err := servers.apply(s); err != nil {
+				log.Fatal(err)
+			}
 
-			targetDir := filepath.Join(args[1], sel[0])
+			targetDir := filepath.Join(args[1], sel.Service)
 			os.MkdirAll(targetDir, 0755)
 
 			w := describeTabWriter()
@@ -105,6 +121,9 @@ func fetchCmd() *cli.Command {
 			})
 		},
 	}
+	cmd.Flags().StringVar(&profileName, "profile", "", "use named profile for credentials and defaults")
+	servers.register(cmd)
+	cassette.register(cmd)
 	return cmd
 }
 
@@ -347,12 +366,7 @@ func isWarning(err error) (string, bool) {
 }
 
 func fetch(op integra.Operation, params map[string]any) (*jsonaccess.Value, error) {
-	req, err := integra.MakeRequest(op, params)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doRequest(op, params)
 	if err != nil {
 		return nil, err
 	}
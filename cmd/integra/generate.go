@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
 
+	"tractor.dev/integra"
 	"tractor.dev/toolkit-go/engine/cli"
 )
 
@@ -16,5 +19,56 @@ func generateCmd() *cli.Command {
 
 		},
 	}
+	cmd.AddCommand(generateSampleCmd())
+	return cmd
+}
+
+func generateSampleCmd() *cli.Command {
+	var (
+		seed  int64
+		count int
+		input bool
+	)
+	cmd := &cli.Command{
+		Usage: "sample <selector>",
+		Short: "generate sample data for an operation output or input",
+		Args:  cli.ExactArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			sel, err := integra.ParseSelector(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if sel.Operation == "" {
+				fmt.Println("selector must be <service>.<resource>.<operation>")
+				os.Exit(1)
+			}
+			if input && len(sel.Schema) == 0 {
+				sel.Schema = []string{"in"}
+			}
+
+			s, err := sel.LoadService()
+			if err != nil {
+				log.Fatal(err)
+			}
+			op, err := sel.ResolveOperation(s)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			schema, err := sel.ResolveSchema(op)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for i := 0; i < count; i++ {
+				if err := printJSON(os.Stdout, integra.Sample(schema, seed+int64(i))); err != nil {
+					log.Fatal(err)
+				}
+			}
+		},
+	}
+	cmd.Flags().Int64Var(&seed, "seed", 1, "seed for the generated data")
+	cmd.Flags().IntVar(&count, "count", 1, "number of samples to generate")
+	cmd.Flags().BoolVar(&input, "input", false, "sample the operation input instead of output")
 	return cmd
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func listenCmd() *cli.Command {
+	var (
+		addr     string
+		secret   string
+		insecure bool
+		strict   bool
+		command  string
+	)
+	cmd := &cli.Command{
+		Usage: "listen <service>",
+		Short: "receive webhook events from a service",
+		Long: `Serves a local HTTP receiver for webhook deliveries from a service. Signatures
+are verified with the secret from --secret or <SERVICE>_WEBHOOK_SECRET using
+the scheme declared for the service, GitHub's X-Hub-Signature-256 by default.
+Only a plain HMAC of the body is supported, not timestamped schemes like
+Stripe's. Without a secret, deliveries are refused unless --insecure is given.
+Payloads are validated against the webhook and callback schemas of the service
+and problems are logged, or rejected with --strict.
+
+Events are printed as JSON lines, or with --exec passed to a shell command on
+stdin with the event name in INTEGRA_EVENT.`,
+		Args: cli.ExactArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			selector, version := integra.SplitSelectorVersion(args[0])
+			s, err := integra.LoadService(selector, version)
+			if err != nil {
+				log.Fatal(err)
+			}
+			rcv := integra.NewWebhookReceiver(s)
+			if secret != "" {
+				rcv.Secret = secret
+			}
+			if rcv.Secret == "" {
+				if !insecure {
+					log.Fatalf("no secret for %s, use --secret or %s_WEBHOOK_SECRET, or --insecure to skip verifying signatures", s.Name(), strings.ReplaceAll(strings.ToUpper(s.Name()), "-", "_"))
+				}
+				log.Printf("warning: no secret for %s, signatures will not be verified", s.Name())
+			}
+			rcv.Strict = strict
+			rcv.Handle = func(e *integra.WebhookEvent) error {
+				for _, problem := range e.Problems {
+					log.Printf("%s: %s", e.Name, problem)
+				}
+				if command != "" {
+					return execEvent(command, s, e)
+				}
+				return printEvent(e)
+			}
+			log.Printf("listening for %s webhooks on http://%s", s.Name(), addr)
+			log.Fatal(http.ListenAndServe(addr, rcv))
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	cmd.Flags().StringVar(&secret, "secret", "", "secret for verifying signatures")
+	cmd.Flags().BoolVar(&insecure, "insecure", false, "accept deliveries without verifying signatures when there is no secret")
+	cmd.Flags().BoolVar(&strict, "strict", false, "reject payloads not matching their schema")
+	cmd.Flags().StringVar(&command, "exec", "", "shell command to run for each event")
+	return cmd
+}
+
+func printEvent(e *integra.WebhookEvent) error {
+	line := map[string]any{
+		"event":   e.Name,
+		"payload": e.Payload,
+	}
+	if e.Webhook != nil {
+		line["webhook"] = e.Webhook.Name()
+	}
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+func execEvent(command string, s integra.Service, e *integra.WebhookEvent) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(e.Body)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"INTEGRA_SERVICE="+s.Name(),
+		"INTEGRA_EVENT="+e.Name,
+	)
+	if e.Webhook != nil {
+		cmd.Env = append(cmd.Env, "INTEGRA_WEBHOOK="+e.Webhook.Name())
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", strings.Fields(command)[0], err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"strings"
 
 	"tractor.dev/toolkit-go/engine/cli"
 )
@@ -19,12 +20,30 @@ func main() {
 		Long:    `integra is an integrations toolchain and utility`,
 	}
 
-	root.AddCommand(authCmd())
-	root.AddCommand(callCmd())
-	root.AddCommand(describeCmd())
-	root.AddCommand(generateCmd())
-	root.AddCommand(devCmd())
-	root.AddCommand(fetchCmd())
+	for _, cmd := range []*cli.Command{
+		authCmd(),
+		callCmd(),
+		describeCmd(),
+		generateCmd(),
+		devCmd(),
+		fetchCmd(),
+		mockCmd(),
+		mcpCmd(),
+		serveCmd(),
+		auditCmd(),
+		shellCmd(),
+		runCmd(),
+		listenCmd(),
+		completionCmd(),
+		completeCmd(),
+	} {
+		root.AddCommand(cmd)
+		name := strings.Fields(cmd.Usage)[0]
+		if !cmd.Hidden {
+			commandNames = append(commandNames, name)
+		}
+		commandFlags[name] = cmd.Flags()
+	}
 
 	if err := cli.Execute(context.Background(), root, os.Args[1:]); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"cmp"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func mcpCmd() *cli.Command {
+	var (
+		allow       string
+		readTools   bool
+		httpAddr    string
+		token       string
+		profileName string
+		cassette    cassetteFlags
+		policy      policyFlags
+	)
+	cmd := &cli.Command{
+		Usage: "mcp <service...>",
+		Short: "serve services over the Model Context Protocol",
+		Long: `Serves services to MCP clients over stdio, or over HTTP with --http.
+Reads of items are exposed as resources and other operations, like
+listing or changing something, are exposed as tools. Over HTTP, only
+loopback addresses are served, and clients must send a bearer token
+from --token, INTEGRA_MCP_TOKEN, or generated and logged at startup. Use --allow to limit which operations
+are exposed by selector, with * matching any name (ex: github.issue.*).`,
+		Args: cli.MinArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			if err := cassette.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := policy.apply(); err != nil {
+				log.Fatal(err)
+			}
+
+			var services []integra.Service
+			for _, arg := range args {
+				name, version := integra.SplitSelectorVersion(arg)
+				s, err := integra.LoadService(name, version)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if err := useProfile(s, profileName); err != nil {
+					log.Fatal(err)
+				}
+				services = append(services, s)
+			}
+
+			server := integra.NewMCPServer(services...)
+			server.Version = Version
+			server.ReadTools = readTools
+			server.Do = doRequestContext
+			patterns := strings.Split(allow, ",")
+			server.Allow = func(op integra.Operation) bool {
+				if activePolicy.Check(op) != nil {
+					return false
+				}
+				return allow == "" || matchSelector(patterns, integra.OperationSelector(op))
+			}
+
+			if httpAddr != "" {
+				if !integra.IsLoopbackAddr(httpAddr) {
+					log.Fatalf("--http must be a loopback address like localhost:8080, got %s", httpAddr)
+				}
+				token = cmp.Or(token, os.Getenv("INTEGRA_MCP_TOKEN"))
+				if token == "" {
+					token = integra.NewLocalToken()
+					log.Printf("clients must send header 'Authorization: Bearer %s'", token)
+				}
+				log.Printf("serving MCP on http://%s", httpAddr)
+				log.Fatal(http.ListenAndServe(httpAddr, &integra.LocalGuard{Handler: server, Token: token}))
+			}
+			if err := server.ServeStdio(os.Stdin, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&allow, "allow", "", "only expose operations matching selectors (ex: github.issue.*,github.repo.get)")
+	cmd.Flags().BoolVar(&readTools, "read-tools", false, "also expose read operations as tools")
+	cmd.Flags().StringVar(&httpAddr, "http", "", "serve over HTTP on loopback address instead of stdio")
+	cmd.Flags().StringVar(&token, "token", "", "bearer token HTTP clients must send (default INTEGRA_MCP_TOKEN or random)")
+	cmd.Flags().StringVar(&profileName, "profile", "", "use named profile for credentials and defaults")
+	cassette.register(cmd)
+	policy.register(cmd)
+	return cmd
+}
+
+// matchSelector reports whether a selector matches any of the patterns
+func matchSelector(patterns []string, selector string) bool {
+	for _, pattern := range patterns {
+		if integra.MatchSelector(strings.TrimSpace(pattern), selector) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func mockCmd() *cli.Command {
+	var (
+		addr string
+	)
+	cmd := &cli.Command{
+		Usage: "mock <service>",
+		Short: "serve a mock implementation of a service",
+		Long: `Serves a local HTTP server implementing every operation of a service with
+responses synthesized from its schemas. Resources created with the mock
+can be listed, fetched, updated and deleted until the mock is stopped.
+Use --base-url with call to make requests to the mock.`,
+		Args: cli.ExactArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			selector, version := integra.SplitSelectorVersion(args[0])
+			s, err := integra.LoadService(selector, version)
+			if err != nil {
+				log.Fatal(err)
+			}
+			mock := integra.NewMock(s)
+			fmt.Printf("serving mock %s on http://%s\n", s.Name(), addr)
+			fmt.Printf("ex: integra call --base-url http://%s %s.<resource>.<operation>\n", addr, s.Name())
+			log.Fatal(http.ListenAndServe(addr, mock))
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	return cmd
+}
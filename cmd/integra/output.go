@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+	"tractor.dev/integra"
+	"tractor.dev/integra/internal/jsonaccess"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+// shapeFlags select what part of a JSON response is printed and how
+type shapeFlags struct {
+	query  string
+	format string
+	unwrap bool
+}
+
+func (f *shapeFlags) register(cmd *cli.Command) {
+	cmd.Flags().StringVar(&f.query, "query", "", "select part of the response (ex: .droplets[].name)")
+	cmd.Flags().StringVar(&f.format, "format", "json", "output format: json, yaml, ndjson, table or csv")
+	cmd.Flags().BoolVar(&f.unwrap, "unwrap", false, "print the items or item instead of the response envelope")
+}
+
+func (f *shapeFlags) validate() error {
+	switch f.format {
+	case "", "json", "yaml", "ndjson", "table", "csv":
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %s", f.format)
+	}
+}
+
+// print shapes a decoded JSON response for an operation and writes it.
+// Tables and CSV are always unwrapped unless a query is given, since
+// their columns come from the properties of the operation output.
+func (f *shapeFlags) print(w io.Writer, op integra.Operation, reply any) error {
+	v := jsonaccess.New(reply)
+	tabular := f.format == "table" || f.format == "csv"
+
+	var schema integra.Schema
+	if f.query == "" && (f.unwrap || tabular) {
+		schema, v = unwrapOutput(op, v)
+	}
+	if f.query != "" {
+		var err error
+		v, err = v.Query(f.query)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch f.format {
+	case "yaml":
+		b, err := yaml.Marshal(yamlValue(v.Data()))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case "ndjson":
+		items := v.Items()
+		if items == nil {
+			items = []*jsonaccess.Value{v}
+		}
+		for _, item := range items {
+			b, err := json.Marshal(item.Data())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, string(b))
+		}
+		return nil
+	case "table", "csv":
+		rows := v.Items()
+		if rows == nil {
+			rows = []*jsonaccess.Value{v}
+		}
+		columns := tableColumns(schema, rows)
+		if f.format == "csv" {
+			return printCSV(w, columns, rows)
+		}
+		return printTable(w, columns, rows)
+	default:
+		return printJSON(w, v.Data())
+	}
+}
+
+// yamlValue converts whole numbers to integers so
+// large values like IDs are not written with exponents
+func yamlValue(v any) any {
+	switch vv := v.(type) {
+	case float64:
+		if vv == math.Trunc(vv) && math.Abs(vv) < 1<<53 {
+			return int64(vv)
+		}
+		return vv
+	case map[string]any:
+		m := make(map[string]any, len(vv))
+		for k, e := range vv {
+			m[k] = yamlValue(e)
+		}
+		return m
+	case []any:
+		l := make([]any, len(vv))
+		for i, e := range vv {
+			l[i] = yamlValue(e)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// unwrapOutput returns the output schema and data of an operation
+// when the response wraps it, like a listing under an "items" key
+func unwrapOutput(op integra.Operation, v *jsonaccess.Value) (integra.Schema, *jsonaccess.Value) {
+	resp, out := op.Response(), op.Output()
+	if resp == nil || out == nil {
+		return out, v
+	}
+	if resp.Name() == out.Name() {
+		return out, v
+	}
+	return out, v.Get(out.Name())
+}
+
+// tableColumns returns columns from the properties of the schema,
+// or from the keys of the rows, with scalar columns first
+func tableColumns(schema integra.Schema, rows []*jsonaccess.Value) []string {
+	if schema != nil && schema.Type() == "array" {
+		schema = schema.Items()
+	}
+	var scalars, others []string
+	if schema != nil && len(schema.Properties()) > 0 {
+		for _, prop := range schema.Properties() {
+			switch prop.Type() {
+			case "string", "integer", "number", "boolean":
+				scalars = append(scalars, prop.Name())
+			default:
+				others = append(others, prop.Name())
+			}
+		}
+		return append(scalars, others...)
+	}
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, key := range row.Keys() {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			switch row.Get(key).Data().(type) {
+			case map[string]any, []any:
+				others = append(others, key)
+			default:
+				scalars = append(scalars, key)
+			}
+		}
+	}
+	slices.Sort(scalars)
+	slices.Sort(others)
+	return append(scalars, others...)
+}
+
+// tableCell formats a value for a table or CSV cell
+func tableCell(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case float64:
+		return formatNumber(vv)
+	case map[string]any, []any:
+		b, _ := json.Marshal(vv)
+		return string(b)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
+
+// rowCells returns the cells of a row, using the row itself
+// as a single value column when it isn't an object
+func rowCells(columns []string, row *jsonaccess.Value) []string {
+	if len(columns) == 0 {
+		return []string{tableCell(row.Data())}
+	}
+	cells := make([]string, len(columns))
+	for i, col := range columns {
+		cells[i] = tableCell(row.Get(col).Data())
+	}
+	return cells
+}
+
+func printTable(w io.Writer, columns []string, rows []*jsonaccess.Value) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(columns) > 0 {
+		for i, col := range columns {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, col)
+		}
+		fmt.Fprintln(tw)
+	}
+	for _, row := range rows {
+		for i, cell := range rowCells(columns, row) {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, shortText(cell))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func printCSV(w io.Writer, columns []string, rows []*jsonaccess.Value) error {
+	cw := csv.NewWriter(w)
+	if len(columns) > 0 {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		if err := cw.Write(rowCells(columns, row)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
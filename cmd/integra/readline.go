@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// lineReader reads lines from the terminal with tab completion and
+// history. The terminal is put in character mode with stty while
+// reading, and without a terminal lines are read as-is.
+type lineReader struct {
+	in       *bufio.Reader
+	out      io.Writer
+	complete func(line string) []string
+	history  []string
+	terminal bool
+}
+
+func newLineReader(complete func(line string) []string) *lineReader {
+	return &lineReader{
+		in:       bufio.NewReader(os.Stdin),
+		out:      os.Stdout,
+		complete: complete,
+		terminal: isTerminal(os.Stdin),
+	}
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// readLine prints the prompt and reads a line, returning io.EOF
+// when input ends or ctrl-d is pressed on an empty line
+func (r *lineReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.terminal {
+		return r.readPlain()
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return r.readPlain()
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return r.readPlain()
+	}
+	defer stty(saved)
+
+	var line []rune
+	pos := len(r.history)
+	redraw := func() {
+		fmt.Fprintf(r.out, "\r\033[K%s%s", prompt, string(line))
+	}
+	for {
+		c, _, err := r.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case '\r', '\n':
+			fmt.Fprintln(r.out)
+			if s := strings.TrimSpace(string(line)); s != "" {
+				r.history = append(r.history, s)
+			}
+			return string(line), nil
+		case 4: // ctrl-d
+			if len(line) == 0 {
+				fmt.Fprintln(r.out)
+				return "", io.EOF
+			}
+		case 3: // ctrl-c
+			fmt.Fprintln(r.out, "^C")
+			line = nil
+			pos = len(r.history)
+			fmt.Fprint(r.out, prompt)
+		case 127, 8: // backspace
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				redraw()
+			}
+		case 21: // ctrl-u
+			line = nil
+			redraw()
+		case '\t':
+			line = []rune(r.completeLine(string(line), prompt))
+			redraw()
+		case 27: // escape sequences, only up and down are used
+			if b, _ := r.in.ReadByte(); b != '[' {
+				continue
+			}
+			switch b, _ := r.in.ReadByte(); b {
+			case 'A':
+				if pos > 0 {
+					pos--
+					line = []rune(r.history[pos])
+					redraw()
+				}
+			case 'B':
+				if pos < len(r.history) {
+					pos++
+					line = nil
+					if pos < len(r.history) {
+						line = []rune(r.history[pos])
+					}
+					redraw()
+				}
+			}
+		default:
+			if c >= ' ' {
+				line = append(line, c)
+				fmt.Fprint(r.out, string(c))
+			}
+		}
+	}
+}
+
+// ask prints a prompt and reads a line as-is, without
+// completion or adding it to the history
+func (r *lineReader) ask(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	return r.readPlain()
+}
+
+func (r *lineReader) readPlain() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// completeLine completes the last word of a line. A single candidate
+// replaces the word, otherwise the common prefix of the candidates
+// is used or the candidates are listed.
+func (r *lineReader) completeLine(line, prompt string) string {
+	if r.complete == nil {
+		return line
+	}
+	candidates := r.complete(line)
+	if len(candidates) == 0 {
+		return line
+	}
+	start := strings.LastIndexAny(line, " \t") + 1
+	word := line[start:]
+	if len(candidates) == 1 {
+		c := candidates[0]
+		if !strings.HasSuffix(c, ".") && !strings.HasSuffix(c, "=") {
+			c += " "
+		}
+		return line[:start] + c
+	}
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(word) {
+		return line[:start] + prefix
+	}
+	fmt.Fprintf(r.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	return line
+}
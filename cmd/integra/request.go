@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+// httpClient performs requests for operations
+var httpClient = http.DefaultClient
+
+// activePolicy is checked before building any request
+var activePolicy *integra.Policy
+
+// activeProfiles are the profiles selected for the running command by service name
+var activeProfiles = map[string]*integra.Profile{}
+
+// useProfile loads a profile for the service and applies it
+func useProfile(s integra.Service, name string) error {
+	p, err := integra.LoadProfile(s.Name(), name)
+	if err != nil || p == nil {
+		return err
+	}
+	if err := p.Apply(s); err != nil {
+		return err
+	}
+	activeProfiles[s.Name()] = p
+	return nil
+}
+
+// readInput reads call input given with --data, which can be
+// a file path prefixed with @, - for stdin, or inline JSON or YAML
+func readInput(spec string) (map[string]any, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch {
+	case spec == "":
+		return map[string]any{}, nil
+	case spec == "-":
+		b, err = io.ReadAll(os.Stdin)
+	case strings.HasPrefix(spec, "@"):
+		b, err = os.ReadFile(spec[1:])
+	default:
+		b = []byte(spec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := integra.ParseInput(b)
+	if err != nil {
+		return nil, fmt.Errorf("reading --data: %w", err)
+	}
+	return data, nil
+}
+
+// fileInput is the content of a file given as input with @path
+type fileInput struct {
+	*bytes.Reader
+	name string
+}
+
+func (f *fileInput) Name() string {
+	return f.name
+}
+
+// readFileInputs replaces input values given on the command line that
+// start with @ with the content of the file at the path after it, like
+// file=@avatar.png. Values starting with @@ are sent with a single @.
+func readFileInputs(data map[string]any) error {
+	for k, v := range data {
+		expanded, err := readFileInput(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w (use @@ for a value starting with @)", k, err)
+		}
+		data[k] = expanded
+	}
+	return nil
+}
+
+func readFileInput(v any) (any, error) {
+	switch vv := v.(type) {
+	case string:
+		if strings.HasPrefix(vv, "@@") {
+			return vv[1:], nil
+		}
+		if len(vv) < 2 || !strings.HasPrefix(vv, "@") {
+			return vv, nil
+		}
+		b, err := os.ReadFile(vv[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &fileInput{Reader: bytes.NewReader(b), name: vv[1:]}, nil
+	case []any:
+		out := make([]any, len(vv))
+		for i, e := range vv {
+			ev, err := readFileInput(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ev
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// buildRequest builds the request for an operation with
+// profile defaults and credentials applied
+func buildRequest(op integra.Operation, data map[string]any) (*http.Request, error) {
+	if err := activePolicy.Check(op); err != nil {
+		return nil, err
+	}
+	profile := activeProfiles[op.Resource().Service().Name()]
+	if profile != nil {
+		data = profile.Defaults(op, data)
+	}
+	req, err := integra.MakeRequest(op, data)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		profile.Authorize(req)
+	}
+	return req, nil
+}
+
+// doRequest builds and performs the request for an operation.
+// Requests other than GET and HEAD are recorded in the audit log.
+func doRequest(op integra.Operation, data map[string]any) (*http.Response, error) {
+	return doRequestContext(context.Background(), op, data)
+}
+
+// doRequestContext is doRequest with a context for the request
+func doRequestContext(ctx context.Context, op integra.Operation, data map[string]any) (*http.Response, error) {
+	req, err := buildRequest(op, data)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return httpClient.Do(req)
+	}
+
+	entry := integra.AuditEntry{
+		Time:        time.Now().UTC(),
+		Selector:    integra.OperationSelector(op),
+		OperationID: op.ID(),
+		Method:      req.Method,
+		URL:         integra.RedactURL(req.URL),
+		Input:       integra.RedactInput(data),
+	}
+	if p := activeProfiles[op.Resource().Service().Name()]; p != nil {
+		entry.Profile = p.Name
+	}
+	resp, err := httpClient.Do(req)
+	entry.DurationMs = time.Since(entry.Time).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Status = resp.StatusCode
+	}
+	if aerr := integra.AppendAudit(entry); aerr != nil {
+		log.Printf("unable to write audit log: %v", aerr)
+	}
+	return resp, err
+}
+
+// policyFlags load the operation policy and adjust it
+type policyFlags struct {
+	readOnly bool
+}
+
+func (f *policyFlags) register(cmd *cli.Command) {
+	cmd.Flags().BoolVar(&f.readOnly, "read-only", false, "only permit GET and HEAD operations")
+}
+
+func (f *policyFlags) apply() error {
+	p, err := integra.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	if f.readOnly {
+		p.ReadOnly = true
+	}
+	activePolicy = p
+	return nil
+}
+
+// confirmDestructive asks before performing an operation that deletes
+// something, unless yes is set, reading the answer with ask. Without a
+// terminal to ask on it fails.
+func confirmDestructive(op integra.Operation, yes bool, ask func(prompt string) (string, error)) error {
+	if yes || !integra.IsDestructive(op) {
+		return nil
+	}
+	selector := integra.OperationSelector(op)
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("%s deletes data, use --yes to confirm", selector)
+	}
+	answer, _ := ask(fmt.Sprintf("%s deletes data. continue? [y/N] ", selector))
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("canceled")
+}
+
+// askStdin prints a prompt to stderr and reads a line from stdin
+func askStdin(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	return bufio.NewReader(os.Stdin).ReadString('\n')
+}
+
+// cassetteFlags record requests to a directory or replay them from one
+type cassetteFlags struct {
+	record string
+	replay string
+}
+
+func (f *cassetteFlags) register(cmd *cli.Command) {
+	cmd.Flags().StringVar(&f.record, "record", "", "record requests and responses to directory")
+	cmd.Flags().StringVar(&f.replay, "replay", "", "replay responses recorded in directory instead of making requests")
+}
+
+func (f *cassetteFlags) apply() error {
+	switch {
+	case f.record != "" && f.replay != "":
+		return fmt.Errorf("cannot use --record and --replay together")
+	case f.record != "":
+		httpClient = &http.Client{Transport: integra.NewRecorder(f.record)}
+	case f.replay != "":
+		httpClient = &http.Client{Transport: integra.NewReplayer(f.replay)}
+	}
+	return nil
+}
+
+// exportFlags are flags for printing a request instead of performing it
+type exportFlags struct {
+	dryRun      bool
+	curl        bool
+	httpie      bool
+	showSecrets bool
+}
+
+func (f *exportFlags) register(cmd *cli.Command) {
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "print the request instead of performing it")
+	cmd.Flags().BoolVar(&f.curl, "curl", false, "print the request as a curl command")
+	cmd.Flags().BoolVar(&f.httpie, "httpie", false, "print the request as an HTTPie command")
+	cmd.Flags().BoolVar(&f.showSecrets, "show-secrets", false, "include credentials when printing the request")
+}
+
+func (f *exportFlags) enabled() bool {
+	return f.dryRun || f.curl || f.httpie
+}
+
+// print writes the request for an operation in the selected form
+func (f *exportFlags) print(op integra.Operation, data map[string]any) error {
+	req, err := buildRequest(op, data)
+	if err != nil {
+		return err
+	}
+	redact := !f.showSecrets
+	var out string
+	switch {
+	case f.curl:
+		out, err = integra.CurlCommand(req, redact)
+	case f.httpie:
+		out, err = integra.HTTPieCommand(req, redact)
+	default:
+		out, err = integra.DumpRequest(req, redact)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.TrimRight(out, "\n"))
+	return nil
+}
+
+// waitFlags poll async operations until they finish
+type waitFlags struct {
+	enabled  bool
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func (f *waitFlags) register(cmd *cli.Command) {
+	cmd.Flags().BoolVar(&f.enabled, "wait", false, "wait for async operations to finish by polling their status")
+	cmd.Flags().DurationVar(&f.timeout, "wait-timeout", 10*time.Minute, "give up waiting after duration")
+	cmd.Flags().DurationVar(&f.interval, "wait-interval", 5*time.Second, "time between polls while waiting")
+}
+
+// print waits for the async operation started by a response, if
+// there is one, and prints the final response like printResponse
+func (f *waitFlags) print(op integra.Operation, resp *http.Response, outputPath string, shape shapeFlags) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode == http.StatusNoContent || (mediaType != "" && !integra.IsJSONMediaType(mediaType)) {
+		return printResponse(resp, outputPath, func(w io.Writer, v any) error {
+			return shape.print(w, op, v)
+		})
+	}
+	var v any
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	async := integra.AsyncOperationFor(op, v)
+	if async == nil {
+		return printOutput(outputPath, func(w io.Writer) error {
+			return shape.print(w, op, v)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	start := time.Now()
+	fmt.Fprintf(os.Stderr, "waiting for %s: %s\n", integra.OperationSelector(op), async.State(v).Status)
+	poll, final, err := async.Wait(ctx, op, v, integra.AsyncWait{
+		Interval: f.interval,
+		Do:       doRequestContext,
+		Progress: func(state integra.AsyncState) {
+			fmt.Fprintf(os.Stderr, "waiting for %s: %s (%s)\n", integra.OperationSelector(op), state.Status, time.Since(start).Round(time.Second))
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return printOutput(outputPath, func(w io.Writer) error {
+		return shape.print(w, poll, final)
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/progrium/clon-go"
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func runCmd() *cli.Command {
+	var (
+		profileName  string
+		servers      serverFlags
+		cassette     cassetteFlags
+		policy       policyFlags
+		yes          bool
+		waitTimeout  time.Duration
+		waitInterval time.Duration
+	)
+	cmd := &cli.Command{
+		Usage: "run <workflow> [inputs...]",
+		Short: "perform the steps of a workflow file",
+		Long: `Performs the steps of a YAML or JSON workflow file. Steps call operations by
+selector with input that can use the workflow inputs and the outputs of previous
+steps, loop over lists with forEach, and be skipped by conditions with if.
+Inputs can be given after the file with CLON syntax, like region=nyc3.
+The output of the workflow is printed as JSON when it declares one.`,
+		Args: cli.MinArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			w, err := integra.ParseWorkflow(b)
+			if err != nil {
+				log.Fatalf("%s: %v", args[0], err)
+			}
+			inputs := map[string]any{}
+			if len(args) > 1 {
+				parsed, err := clon.Parse(args[1:])
+				if err != nil {
+					log.Fatal(err)
+				}
+				inputs = parsed.(map[string]any)
+			}
+			if err := cassette.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := policy.apply(); err != nil {
+				log.Fatal(err)
+			}
+
+			loaded := map[string]integra.Service{}
+			runner := &integra.WorkflowRunner{
+				Operation: func(selector string) (integra.Operation, error) {
+					sel, err := integra.ParseSelector(selector)
+					if err != nil {
+						return nil, err
+					}
+					key := sel.Service + "@" + sel.Version
+					s, ok := loaded[key]
+					if !ok {
+						if s, err = sel.LoadService(); err != nil {
+							return nil, err
+						}
+						if err := useProfile(s, profileName); err != nil {
+							return nil, err
+						}
+						if err := servers.apply(s); err != nil {
+							return nil, err
+						}
+						loaded[key] = s
+					}
+					return sel.ResolveOperation(s)
+				},
+				Do: func(ctx context.Context, op integra.Operation, in map[string]any) (*http.Response, error) {
+					if err := confirmDestructive(op, yes, askStdin); err != nil {
+						return nil, err
+					}
+					return doRequestContext(ctx, op, in)
+				},
+				Logf: func(format string, args ...any) {
+					fmt.Fprintf(os.Stderr, format+"\n", args...)
+				},
+				WaitTimeout:  waitTimeout,
+				WaitInterval: waitInterval,
+			}
+			out, err := runner.Run(context.Background(), w, inputs)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if out != nil {
+				if err := printJSON(os.Stdout, out); err != nil {
+					log.Fatal(err)
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&profileName, "profile", "", "use named profile for credentials and defaults")
+	servers.register(cmd)
+	cassette.register(cmd)
+	policy.register(cmd)
+	cmd.Flags().BoolVar(&yes, "yes", false, "perform delete operations without asking")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 10*time.Minute, "give up waiting on a step after duration")
+	cmd.Flags().DurationVar(&waitInterval, "wait-interval", 5*time.Second, "time between polls while waiting on a step")
+	return cmd
+}
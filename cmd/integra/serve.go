@@ -0,0 +1,113 @@
+package main
+
+import (
+	"cmp"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func serveCmd() *cli.Command {
+	var (
+		addr        string
+		token       string
+		allow       string
+		write       string
+		clientsPath string
+		cacheTTL    time.Duration
+		profileName string
+		cassette    cassetteFlags
+		policy      policyFlags
+	)
+	cmd := &cli.Command{
+		Usage: "serve <service...>",
+		Short: "serve services through one local REST gateway",
+		Long: `Serves operations of services under routes like /github/repo/get?owner=..&repo=..
+so applications can use one local gateway instead of each service's API. Input
+is given as query parameters or a JSON body. Read operations are routed for GET
+and others for POST. Credentials come from the environment and --profile on the
+gateway, so applications never hold them.
+
+Only loopback addresses are served, and clients must send a bearer token from
+--token, INTEGRA_GATEWAY_TOKEN, or generated and logged at startup. Read
+operations matching --allow are served, all by default, and operations changing
+something are only served if they match --write, with * matching any name
+(ex: github.issue.*). Use --clients for a YAML file of clients with their own
+tokens and read and write routes instead. Use --cache to cache responses of
+read operations. GET / lists the routes a client may use.`,
+		Args: cli.MinArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			if !integra.IsLoopbackAddr(addr) {
+				log.Fatalf("--addr must be a loopback address like localhost:8080, got %s", addr)
+			}
+			if err := cassette.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := policy.apply(); err != nil {
+				log.Fatal(err)
+			}
+
+			var services []integra.Service
+			for _, arg := range args {
+				name, version := integra.SplitSelectorVersion(arg)
+				s, err := integra.LoadService(name, version)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if err := useProfile(s, profileName); err != nil {
+					log.Fatal(err)
+				}
+				services = append(services, s)
+			}
+
+			gateway := integra.NewGateway(services...)
+			gateway.Do = doRequestContext
+			gateway.CacheTTL = cacheTTL
+			gateway.Allow = func(op integra.Operation) bool {
+				return activePolicy.Check(op) == nil
+			}
+			if clientsPath != "" {
+				if token != "" || allow != "" || write != "" {
+					log.Fatal("--clients can't be used with --token, --allow or --write")
+				}
+				clients, err := integra.LoadGatewayClients(clientsPath)
+				if err != nil {
+					log.Fatal(err)
+				}
+				gateway.Clients = clients
+			} else {
+				token = cmp.Or(token, os.Getenv("INTEGRA_GATEWAY_TOKEN"))
+				if token == "" {
+					token = integra.NewLocalToken()
+					log.Printf("clients must send header 'Authorization: Bearer %s'", token)
+				}
+				client := &integra.GatewayClient{Token: token, Read: []string{"*"}}
+				if allow != "" {
+					client.Read = strings.Split(allow, ",")
+				}
+				if write != "" {
+					client.Write = strings.Split(write, ",")
+				}
+				gateway.Clients = map[string]*integra.GatewayClient{"default": client}
+			}
+
+			log.Printf("serving gateway on http://%s", addr)
+			log.Fatal(http.ListenAndServe(addr, gateway))
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "loopback address to listen on")
+	cmd.Flags().StringVar(&token, "token", "", "bearer token clients must send (default $INTEGRA_GATEWAY_TOKEN or generated)")
+	cmd.Flags().StringVar(&allow, "allow", "", "only serve read operations matching selectors (ex: github.issue.*,github.repo.get)")
+	cmd.Flags().StringVar(&write, "write", "", "serve operations changing something matching selectors (ex: github.issue.create)")
+	cmd.Flags().StringVar(&clientsPath, "clients", "", "YAML file of clients with their tokens and routes")
+	cmd.Flags().DurationVar(&cacheTTL, "cache", 0, "cache responses of read operations for duration (ex: 30s)")
+	cmd.Flags().StringVar(&profileName, "profile", "", "use named profile for credentials and defaults")
+	cassette.register(cmd)
+	policy.register(cmd)
+	return cmd
+}
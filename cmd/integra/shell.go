@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/progrium/clon-go"
+	"tractor.dev/integra"
+	"tractor.dev/integra/internal/jsonaccess"
+	"tractor.dev/toolkit-go/engine/cli"
+)
+
+func shellCmd() *cli.Command {
+	var (
+		profileName string
+		servers     serverFlags
+		cassette    cassetteFlags
+		policy      policyFlags
+	)
+	cmd := &cli.Command{
+		Usage: "shell <service>",
+		Short: "explore and call a service interactively",
+		Long: `Starts an interactive shell for a service. Use cd to enter resources, ls to
+list resources and operations, describe to show details, and run operations
+by name with key=value arguments. The result of each call is stored in $last,
+and set binds values to names, so later calls can use $last.id or $droplet.name.
+Type help for all commands.`,
+		Args: cli.ExactArgs(1),
+		Run: func(ctx *cli.Context, args []string) {
+			sel, err := integra.ParseSelector(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			s, err := sel.LoadService()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := cassette.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := policy.apply(); err != nil {
+				log.Fatal(err)
+			}
+			if err := useProfile(s, profileName); err != nil {
+				log.Fatal(err)
+			}
+			if err := servers.apply(s); err != nil {
+				log.Fatal(err)
+			}
+
+			sh := &shell{service: s, vars: map[string]any{}, out: os.Stdout}
+			if sel.Resource != "" {
+				if sh.resource, err = sel.ResolveResource(s); err != nil {
+					log.Fatal(err)
+				}
+			}
+			sh.in = newLineReader(sh.complete)
+			sh.run()
+		},
+	}
+	cmd.Flags().StringVar(&profileName, "profile", "", "use named profile for credentials and defaults")
+	servers.register(cmd)
+	cassette.register(cmd)
+	policy.register(cmd)
+	return cmd
+}
+
+// shell is the state of an interactive shell on a service
+type shell struct {
+	service  integra.Service
+	resource integra.Resource
+	vars     map[string]any
+	in       *lineReader
+	out      io.Writer
+}
+
+var shellCommands = []string{"cd", "ls", "describe", "call", "set", "print", "vars", "help", "exit"}
+
+const shellHelp = `commands:
+  ls                        list resources, or operations of the current resource
+  cd <resource>             enter a resource (.. or / to go back to the service)
+  describe [name]           describe the service, a resource or an operation
+  <operation> [key=value]   perform an operation of the current resource
+  call <selector> [...]     perform an operation by resource.operation selector
+  set <name> <value>        bind a value, like set id $last.droplet.id
+  print <value>             print a value, like print $last.droplets[0]
+  vars                      list bound names
+  exit                      leave the shell
+
+Arguments can use $name and $name.path to refer to bound values.
+The result of each call is bound to $last.
+`
+
+func (sh *shell) prompt() string {
+	p := sh.service.Name()
+	if sh.resource != nil {
+		p += "/" + sh.resource.Name()
+	}
+	return p + "> "
+}
+
+func (sh *shell) run() {
+	for {
+		line, err := sh.in.readLine(sh.prompt())
+		if err != nil {
+			return
+		}
+		words, err := splitShellWords(line)
+		if err != nil {
+			fmt.Fprintln(sh.out, err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if words[0] == "exit" || words[0] == "quit" {
+			return
+		}
+		if err := sh.exec(words); err != nil {
+			fmt.Fprintln(sh.out, err)
+		}
+	}
+}
+
+func (sh *shell) exec(words []string) error {
+	switch words[0] {
+	case "help":
+		fmt.Fprint(sh.out, shellHelp)
+		return nil
+	case "ls":
+		sh.list()
+		return nil
+	case "cd":
+		return sh.cd(words[1:])
+	case "describe":
+		return sh.describe(words[1:])
+	case "set":
+		if len(words) < 3 {
+			return fmt.Errorf("usage: set <name> <value>")
+		}
+		v, err := sh.expand(strings.Join(words[2:], " "))
+		if err != nil {
+			return err
+		}
+		sh.vars[words[1]] = v
+		return nil
+	case "print":
+		v, err := sh.expand(strings.Join(words[1:], " "))
+		if err != nil {
+			return err
+		}
+		return printJSON(sh.out, v)
+	case "vars":
+		names := make([]string, 0, len(sh.vars))
+		for name := range sh.vars {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Fprintf(sh.out, "$%s\n", name)
+		}
+		return nil
+	case "call":
+		if len(words) < 2 {
+			return fmt.Errorf("usage: call <resource>.<operation> [key=value...]")
+		}
+		op, err := sh.operation(words[1])
+		if err != nil {
+			return err
+		}
+		return sh.call(op, words[2:])
+	}
+	if sh.resource == nil {
+		return fmt.Errorf("unknown command: %s (type help for commands)", words[0])
+	}
+	op, err := sh.operation(words[0])
+	if err != nil {
+		return err
+	}
+	return sh.call(op, words[1:])
+}
+
+// operation resolves an operation name in the current
+// resource, or a resource.operation selector
+func (sh *shell) operation(name string) (integra.Operation, error) {
+	selector := sh.service.Name() + "." + name
+	if sh.resource != nil && !strings.Contains(name, ".") {
+		selector = sh.service.Name() + "." + sh.resource.Name() + "." + name
+	}
+	sel, err := integra.ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return sel.ResolveOperation(sh.service)
+}
+
+func (sh *shell) list() {
+	w := describeTabWriter()
+	defer w.Flush()
+	if sh.resource == nil {
+		for _, r := range sh.service.Resources() {
+			fmt.Fprintf(w, "%s/\t%s\n", r.Name(), shortText(r.Title()))
+		}
+		return
+	}
+	for _, sub := range sh.resource.Subresources() {
+		fmt.Fprintf(w, "%s/\t%s\n", sub.Name(), shortText(sub.Title()))
+	}
+	for _, op := range sh.resource.Operations() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", op.Name(), strings.ToUpper(op.Method()), shortText(op.Description()))
+	}
+}
+
+func (sh *shell) cd(args []string) error {
+	if len(args) == 0 || args[0] == "/" {
+		sh.resource = nil
+		return nil
+	}
+	if args[0] == ".." {
+		if sh.resource != nil {
+			sh.resource = sh.resource.Parent()
+		}
+		return nil
+	}
+	sel := integra.Selector{Service: sh.service.Name(), Resource: strings.Trim(args[0], "/")}
+	r, err := sel.ResolveResource(sh.service)
+	if err != nil {
+		return err
+	}
+	sh.resource = r
+	return nil
+}
+
+func (sh *shell) describe(args []string) error {
+	if len(args) == 0 {
+		if sh.resource == nil {
+			describeService(sh.service)
+		} else {
+			describeResource(sh.resource)
+		}
+		return nil
+	}
+	if sh.resource != nil && !strings.Contains(args[0], ".") {
+		if op, err := sh.operation(args[0]); err == nil {
+			describeOperation(op)
+			return nil
+		}
+	}
+	sel, err := integra.ParseSelector(sh.service.Name() + "." + args[0])
+	if err != nil {
+		return err
+	}
+	if sel.Operation == "" {
+		r, err := sel.ResolveResource(sh.service)
+		if err != nil {
+			return err
+		}
+		describeResource(r)
+		return nil
+	}
+	op, err := sel.ResolveOperation(sh.service)
+	if err != nil {
+		return err
+	}
+	if len(sel.Schema) > 0 {
+		schema, err := sel.ResolveSchema(op)
+		if err != nil {
+			return err
+		}
+		describeSchema(schema)
+		return nil
+	}
+	describeOperation(op)
+	return nil
+}
+
+// call performs an operation with key=value arguments
+// and binds a JSON result to $last
+func (sh *shell) call(op integra.Operation, args []string) error {
+	data := map[string]any{}
+	if len(args) > 0 {
+		parsed, err := clon.Parse(args)
+		if err != nil {
+			return err
+		}
+		m, ok := parsed.(map[string]any)
+		if !ok {
+			return fmt.Errorf("arguments must be key=value pairs")
+		}
+		if err := readFileInputs(m); err != nil {
+			return err
+		}
+		expanded, err := sh.expandValue(m)
+		if err != nil {
+			return err
+		}
+		data = expanded.(map[string]any)
+	}
+	if err := confirmDestructive(op, false, sh.in.ask); err != nil {
+		return err
+	}
+	resp, err := doRequest(op, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("%s\n%s", resp.Status, body)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var v any
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		if len(body) == 0 {
+			fmt.Fprintln(sh.out, resp.Status)
+		} else if isTextMediaType(mediaType) {
+			fmt.Fprintln(sh.out, string(body))
+		} else {
+			fmt.Fprintf(sh.out, "%s (%d bytes of %s)\n", resp.Status, len(body), mediaType)
+		}
+		return nil
+	}
+	sh.vars["last"] = v
+	return printJSON(sh.out, v)
+}
+
+var shellVarRef = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)((?:\.[A-Za-z0-9_-]+|\[[^\]]*\])*)`)
+
+// expand returns the value of a string where $name.path references
+// bound values. A string that is only a reference gives the value
+// itself, otherwise references are replaced by their text.
+func (sh *shell) expand(s string) (any, error) {
+	if loc := shellVarRef.FindStringIndex(s); loc != nil && loc[0] == 0 && loc[1] == len(s) {
+		return sh.lookup(s)
+	}
+	var lookupErr error
+	expanded := shellVarRef.ReplaceAllStringFunc(s, func(ref string) string {
+		v, err := sh.lookup(ref)
+		if err != nil {
+			lookupErr = err
+			return ref
+		}
+		return tableCell(v)
+	})
+	return expanded, lookupErr
+}
+
+// expandValue expands references in the strings of parsed arguments
+func (sh *shell) expandValue(v any) (any, error) {
+	switch vv := v.(type) {
+	case string:
+		return sh.expand(vv)
+	case map[string]any:
+		out := make(map[string]any, len(vv))
+		for k, e := range vv {
+			ev, err := sh.expandValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = ev
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(vv))
+		for i, e := range vv {
+			ev, err := sh.expandValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ev
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func (sh *shell) lookup(ref string) (any, error) {
+	m := shellVarRef.FindStringSubmatch(ref)
+	v, ok := sh.vars[m[1]]
+	if !ok {
+		return nil, fmt.Errorf("$%s is not set", m[1])
+	}
+	if m[2] == "" {
+		return v, nil
+	}
+	found, err := jsonaccess.New(v).Query(m[2])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	if found.IsNil() {
+		return nil, fmt.Errorf("%s: not found", ref)
+	}
+	return found.Data(), nil
+}
+
+// complete returns candidates for the last word of a line
+func (sh *shell) complete(line string) []string {
+	words := strings.Fields(line)
+	if line == "" || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	current := words[len(words)-1]
+	var names []string
+	switch {
+	case len(words) == 1:
+		names = append(names, shellCommands...)
+		if sh.resource != nil {
+			for _, op := range sh.resource.Operations() {
+				names = append(names, op.Name())
+			}
+		}
+	case words[0] == "cd":
+		names = append(names, "..", "/")
+		for _, r := range sh.service.Resources() {
+			names = append(names, r.Name())
+		}
+	case words[0] == "describe" || (words[0] == "call" && len(words) == 2):
+		if sh.resource != nil && words[0] == "describe" {
+			for _, op := range sh.resource.Operations() {
+				names = append(names, op.Name())
+			}
+		}
+		for _, c := range completeSelector(sh.service.Name() + "." + current) {
+			names = append(names, strings.TrimPrefix(c, sh.service.Name()+"."))
+		}
+	default:
+		name := words[0]
+		if name == "call" {
+			name = words[1]
+		}
+		op, err := sh.operation(name)
+		if err != nil {
+			return nil
+		}
+		return completeParameters(integra.OperationSelector(op), current)
+	}
+	return slices.Compact(withPrefix(names, current))
+}
+
+// splitShellWords splits a line into words separated by spaces,
+// keeping quoted text together
+func splitShellWords(line string) (words []string, err error) {
+	var (
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range line {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
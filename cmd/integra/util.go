@@ -1,14 +1,66 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
 	"tractor.dev/integra"
+	"tractor.dev/toolkit-go/engine/cli"
 )
 
+// serverFlags select which server a service is called on
+type serverFlags struct {
+	server     string
+	serverVars keyValueFlag
+	baseURL    string
+}
+
+func (f *serverFlags) register(cmd *cli.Command) {
+	cmd.Flags().StringVar(&f.server, "server", "", "use declared server by index, URL or description")
+	cmd.Flags().Var(&f.serverVars, "server-var", "set a server variable, can be repeated (ex: region=nyc)")
+	cmd.Flags().StringVar(&f.baseURL, "base-url", "", "override the base URL of the service")
+}
+
+func (f *serverFlags) apply(s integra.Service) error {
+	if f.baseURL != "" {
+		s.SetBaseURL(f.baseURL)
+		return nil
+	}
+	if f.server == "" && len(f.serverVars) == 0 {
+		return nil
+	}
+	return integra.SelectServer(s, f.server, f.serverVars)
+}
+
+// keyValueFlag is a flag of key=value pairs, set by repeating it
+type keyValueFlag map[string]string
+
+func (f *keyValueFlag) String() string {
+	var pairs []string
+	for k, v := range *f {
+		pairs = append(pairs, k+"="+v)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, " ")
+}
+
+func (f *keyValueFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value: %s", s)
+	}
+	if *f == nil {
+		*f = make(keyValueFlag)
+	}
+	(*f)[strings.TrimSpace(k)] = v
+	return nil
+}
+
 func truncateText(s string) string {
 	if len(s) > 50 {
 		s = s[:50] + "..."
@@ -22,25 +74,61 @@ func shortText(s string) string {
 }
 
 func schemaFeatures(schema integra.Schema) (features []string) {
-	// TODO: more features
 	if schema.Required() {
 		features = append(features, "required")
 	}
+	if schema.Deprecated() {
+		features = append(features, "deprecated")
+	}
 	if schema.Format() != "" {
 		features = append(features, fmt.Sprintf("format: %s", schema.Format()))
 	}
-	// if ss.MinLength != nil {
-	// 	features = append(features, fmt.Sprintf("min-length: %d", *ss.MinLength))
-	// }
-	// if ss.MaxLength != nil {
-	// 	features = append(features, fmt.Sprintf("max-length: %d", *ss.MaxLength))
-	// }
+	if schema.Pattern() != "" {
+		features = append(features, fmt.Sprintf("pattern: %s", schema.Pattern()))
+	}
+	if schema.Const() != "" {
+		features = append(features, fmt.Sprintf("const: %s", schema.Const()))
+	}
+	if schema.Minimum() != nil {
+		features = append(features, fmt.Sprintf("minimum: %s", formatNumber(*schema.Minimum())))
+	}
+	if schema.Maximum() != nil {
+		features = append(features, fmt.Sprintf("maximum: %s", formatNumber(*schema.Maximum())))
+	}
+	if schema.MultipleOf() != nil {
+		features = append(features, fmt.Sprintf("multiple-of: %s", formatNumber(*schema.MultipleOf())))
+	}
+	if schema.MinLength() != nil {
+		features = append(features, fmt.Sprintf("min-length: %d", *schema.MinLength()))
+	}
+	if schema.MaxLength() != nil {
+		features = append(features, fmt.Sprintf("max-length: %d", *schema.MaxLength()))
+	}
+	if schema.MinItems() != nil {
+		features = append(features, fmt.Sprintf("min-items: %d", *schema.MinItems()))
+	}
+	if schema.MaxItems() != nil {
+		features = append(features, fmt.Sprintf("max-items: %d", *schema.MaxItems()))
+	}
+	if schema.UniqueItems() {
+		features = append(features, "unique-items")
+	}
+	if additional := schema.AdditionalProperties(); additional != nil {
+		features = append(features, fmt.Sprintf("additional-properties: %s", cmp.Or(additional.Type(), "any")))
+	}
 	if schema.ReadOnly() {
 		features = append(features, "read-only")
 	}
+	if schema.WriteOnly() {
+		features = append(features, "write-only")
+	}
 	return
 }
 
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
 func open(s string) error {
 	// https://github.com/skratchdot/open-golang/blob/master/open
 	switch runtime.GOOS {
@@ -0,0 +1,184 @@
+package integra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// requestMediaType selects which of the declared request media types
+// to encode data with. JSON is preferred unless the data has file
+// content, in which case multipart or raw bodies are preferred.
+func requestMediaType(mediaTypes []string, data map[string]any) string {
+	if hasFileValues(data) {
+		if slices.Contains(mediaTypes, "multipart/form-data") {
+			return "multipart/form-data"
+		}
+		for _, mediaType := range mediaTypes {
+			if isRawMediaType(mediaType) {
+				return mediaType
+			}
+		}
+	}
+	return PreferredMediaType(mediaTypes)
+}
+
+// encodeBody encodes data as a request body of the given media type,
+// returning the body and the full content type to send with it.
+//
+// For multipart and raw bodies, io.Reader and []byte values are sent
+// as file content. Readers with a Name method, like *os.File, are sent
+// with the base of their name as filename. Strings are never read as
+// files, so input from the network can't send local files. Raw bodies
+// are made from a single value in data.
+func encodeBody(mediaType string, data map[string]any) (io.Reader, string, error) {
+	baseType, _, _ := mime.ParseMediaType(mediaType)
+	switch {
+	case IsJSONMediaType(mediaType):
+		if hasFileValues(data) {
+			return nil, "", fmt.Errorf("file content can't be sent in a %s body", mediaType)
+		}
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(b), mediaType, nil
+
+	case baseType == "application/x-www-form-urlencoded":
+		form := url.Values{}
+		for k, v := range data {
+			addFormValue(form, k, v)
+		}
+		return strings.NewReader(form.Encode()), mediaType, nil
+
+	case baseType == "multipart/form-data":
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for _, k := range slices.Sorted(maps.Keys(data)) {
+			if err := writeMultipartValue(w, k, data[k]); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, "", err
+		}
+		return &buf, w.FormDataContentType(), nil
+
+	default:
+		if len(data) != 1 {
+			return nil, "", fmt.Errorf("%s body needs a single value, got: %v", mediaType, slices.Sorted(maps.Keys(data)))
+		}
+		for _, v := range data {
+			r, _, err := valueReader(v)
+			if err != nil {
+				return nil, "", err
+			}
+			if mediaType == "*/*" {
+				mediaType = "application/octet-stream"
+			}
+			return r, mediaType, nil
+		}
+	}
+	return nil, "", nil
+}
+
+func writeMultipartValue(w *multipart.Writer, key string, v any) error {
+	if vals, ok := v.([]any); ok {
+		for _, vv := range vals {
+			if err := writeMultipartValue(w, key, vv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !isFileValue(v) {
+		return w.WriteField(key, formString(v))
+	}
+	r, filename, err := valueReader(v)
+	if err != nil {
+		return err
+	}
+	part, err := w.CreateFormFile(key, filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, r)
+	return err
+}
+
+// valueReader returns a reader for the content of a value
+// and the filename to send it with
+func valueReader(v any) (io.Reader, string, error) {
+	switch vv := v.(type) {
+	case io.Reader:
+		if named, ok := vv.(interface{ Name() string }); ok && named.Name() != "" {
+			return vv, filepath.Base(named.Name()), nil
+		}
+		return vv, "blob", nil
+	case []byte:
+		return bytes.NewReader(vv), "blob", nil
+	}
+	return strings.NewReader(formString(v)), "blob", nil
+}
+
+// isFileValue reports whether a value is file content
+func isFileValue(v any) bool {
+	switch v.(type) {
+	case io.Reader, []byte:
+		return true
+	}
+	return false
+}
+
+func hasFileValues(data map[string]any) bool {
+	for _, v := range data {
+		if isFileValue(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRawMediaType(mediaType string) bool {
+	if IsJSONMediaType(mediaType) {
+		return false
+	}
+	baseType, _, _ := mime.ParseMediaType(mediaType)
+	return baseType != "application/x-www-form-urlencoded" &&
+		baseType != "multipart/form-data"
+}
+
+// addFormValue adds a value to form or query values,
+// adding each element of a list as a separate value
+func addFormValue(form url.Values, key string, v any) {
+	if vals, ok := v.([]any); ok {
+		for _, vv := range vals {
+			form.Add(key, formString(vv))
+		}
+		return
+	}
+	form.Add(key, formString(v))
+}
+
+// formString formats scalars as strings and anything else as JSON
+func formString(v any) string {
+	switch vv := v.(type) {
+	case float64:
+		// avoid exponents for large whole numbers like IDs
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,200 @@
+package integra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted replaces secret values when requests are displayed
+const Redacted = "REDACTED"
+
+var secretHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+var secretQueryParams = []string{
+	"key",
+	"api_key",
+	"apikey",
+	"access_token",
+	"token",
+	"client_secret",
+}
+
+// RedactHeader returns a copy of h with secret values redacted
+func RedactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if slices.Contains(secretHeaders, http.CanonicalHeaderKey(name)) {
+			out[name] = []string{Redacted}
+		}
+	}
+	return out
+}
+
+// RedactURL returns the URL with secret query parameters redacted
+func RedactURL(u *url.URL) string {
+	q := u.Query()
+	redacted := false
+	for name := range q {
+		if slices.Contains(secretQueryParams, strings.ToLower(name)) {
+			q[name] = []string{Redacted}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	uu := *u
+	uu.RawQuery = q.Encode()
+	return uu.String()
+}
+
+// requestBody reads the body of a request and
+// replaces it so the request can still be sent
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	return b, nil
+}
+
+func isPrintable(b []byte) bool {
+	return utf8.Valid(b) && !bytes.ContainsRune(b, 0)
+}
+
+// commandBody returns a body to include in a command line,
+// refusing binary data that couldn't be reproduced from it
+func commandBody(body []byte) (string, error) {
+	if !isPrintable(body) {
+		return "", fmt.Errorf("request body has %d bytes of binary data, which can't be exported as a command line", len(body))
+	}
+	return string(body), nil
+}
+
+func requestURL(req *http.Request, redact bool) string {
+	if redact {
+		return RedactURL(req.URL)
+	}
+	return req.URL.String()
+}
+
+func requestHeader(req *http.Request, redact bool) http.Header {
+	if redact {
+		return RedactHeader(req.Header)
+	}
+	return req.Header
+}
+
+func sortedHeaderNames(h http.Header) []string {
+	var names []string
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// DumpRequest formats a request like it would be sent over HTTP/1.1
+func DumpRequest(req *http.Request, redact bool) (string, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", req.Method, requestURL(req, redact))
+	header := requestHeader(req, redact)
+	for _, name := range sortedHeaderNames(header) {
+		for _, v := range header[name] {
+			fmt.Fprintf(&sb, "%s: %s\n", name, v)
+		}
+	}
+	if len(body) > 0 {
+		sb.WriteString("\n")
+		if isPrintable(body) {
+			sb.Write(body)
+			sb.WriteString("\n")
+		} else {
+			fmt.Fprintf(&sb, "(%d bytes of binary data)\n", len(body))
+		}
+	}
+	return sb.String(), nil
+}
+
+// CurlCommand formats a request as a curl command line. Requests
+// with binary bodies, like file uploads, return an error.
+func CurlCommand(req *http.Request, redact bool) (string, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"curl", "-X", req.Method, shellQuote(requestURL(req, redact))}
+	header := requestHeader(req, redact)
+	for _, name := range sortedHeaderNames(header) {
+		for _, v := range header[name] {
+			args = append(args, "-H", shellQuote(fmt.Sprintf("%s: %s", name, v)))
+		}
+	}
+	if len(body) > 0 {
+		data, err := commandBody(body)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--data-binary", shellQuote(data))
+	}
+	return strings.Join(args, " "), nil
+}
+
+// HTTPieCommand formats a request as an HTTPie command line. Requests
+// with binary bodies, like file uploads, return an error.
+func HTTPieCommand(req *http.Request, redact bool) (string, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return "", err
+	}
+	args := []string{"http", "--ignore-stdin"}
+	if len(body) > 0 {
+		data, err := commandBody(body)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--raw", shellQuote(data))
+	}
+	args = append(args, req.Method, shellQuote(requestURL(req, redact)))
+	header := requestHeader(req, redact)
+	for _, name := range sortedHeaderNames(header) {
+		for _, v := range header[name] {
+			args = append(args, shellQuote(fmt.Sprintf("%s:%s", name, v)))
+		}
+	}
+	return strings.Join(args, " "), nil
+}
+
+// shellQuote quotes a string for POSIX shells if needed
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,81 @@
+package integra
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCurlCommand(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "user", "update")
+
+	req, err := MakeRequest(op, map[string]any{"id": "1", "name": "jeff's"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+
+	cmd, err := CurlCommand(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `curl -X PATCH https://api.example.com/users/1 -H 'Authorization: REDACTED' -H 'Content-Type: application/x-www-form-urlencoded' --data-binary name=jeff%27s`
+	if cmd != expected {
+		t.Errorf("curl = %s; want %s", cmd, expected)
+	}
+
+	cmd, err = HTTPieCommand(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `http --ignore-stdin --raw name=jeff%27s PATCH https://api.example.com/users/1 'Authorization:Bearer secret' Content-Type:application/x-www-form-urlencoded`
+	if cmd != expected {
+		t.Errorf("httpie = %s; want %s", cmd, expected)
+	}
+
+	b, _ := io.ReadAll(req.Body)
+	if string(b) != "name=jeff%27s" {
+		t.Errorf("body not restored: %q", b)
+	}
+
+	req, err = http.NewRequest("POST", "https://api.example.com/upload", bytes.NewReader([]byte{0x89, 'P', 'N', 'G', 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CurlCommand(req, true); err == nil {
+		t.Error("expected error exporting binary body with curl")
+	}
+	if _, err := HTTPieCommand(req, true); err == nil {
+		t.Error("expected error exporting binary body with httpie")
+	}
+}
+
+func TestDumpRequest(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "user", "get")
+
+	req, err := MakeRequest(op, map[string]any{"id": "1", "X-Trace": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := req.URL.Query()
+	q.Set("key", "secret")
+	req.URL.RawQuery = q.Encode()
+
+	out, err := DumpRequest(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "GET https://api.example.com/users/1?key=REDACTED\n") {
+		t.Errorf("unexpected request line: %s", out)
+	}
+	if !strings.Contains(out, "X-Trace: abc\n") {
+		t.Errorf("missing header: %s", out)
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("secret not redacted: %s", out)
+	}
+}
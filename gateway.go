@@ -0,0 +1,432 @@
+package integra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// gatewayHeaders are response headers passed through from services
+var gatewayHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Retry-After"}
+
+// Gateway serves operations of services under normalized routes like
+// /github/repo/get?owner=tractordev&repo=integra, so applications can
+// use one local HTTP API instead of each service's API. Input is taken
+// from the query and from a JSON body. Read operations are routed for
+// GET and others for POST or their own method, so a link can never
+// change anything.
+//
+// Gateways only serve loopback hosts and origins, like a LocalGuard.
+// Clients send the bearer token of one of Clients and may only use
+// the routes it allows.
+type Gateway struct {
+	// Allow reports whether an operation is served to any
+	// client. All operations are served if Allow is nil.
+	Allow func(op Operation) bool
+
+	// Clients are the clients of the gateway by name
+	Clients map[string]*GatewayClient
+
+	// MaxBodySize limits the size of request bodies, 10MB if zero
+	MaxBodySize int64
+
+	// Do performs operations, see DoFunc
+	Do DoFunc
+
+	// CacheTTL is how long successful responses of read
+	// operations are cached. Caching is disabled if zero.
+	CacheTTL time.Duration
+
+	services map[string]Service
+
+	mu    sync.Mutex
+	cache map[string]*gatewayResponse
+}
+
+// GatewayClient is an application using a gateway, with the routes it
+// may use as selector patterns where * matches any name. Clients are
+// configured in YAML, with tokens expanded from the environment:
+//
+//	clients:
+//	  web:
+//	    token: ${WEB_GATEWAY_TOKEN}
+//	    read: [github.repo.get, github.issue.list]
+//	  worker:
+//	    token: ${WORKER_GATEWAY_TOKEN}
+//	    read: [github.issue.*]
+//	    write: [github.issue.create, github.issue.update]
+type GatewayClient struct {
+	// Token is sent by the client as a bearer token
+	Token string `yaml:"token"`
+
+	// Read are the read operations the client may perform
+	Read []string `yaml:"read"`
+
+	// Write are operations the client may perform including ones
+	// changing something, which are never allowed by Read
+	Write []string `yaml:"write"`
+}
+
+// LoadGatewayClients loads gateway clients from a YAML file
+func LoadGatewayClients(path string) (map[string]*GatewayClient, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		Clients map[string]*GatewayClient `yaml:"clients"`
+	}
+	if err := yaml.UnmarshalStrict(b, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	tokens := map[string]bool{}
+	for name, c := range config.Clients {
+		if c == nil {
+			return nil, fmt.Errorf("%s: client %s has no token", path, name)
+		}
+		c.Token = os.ExpandEnv(c.Token)
+		if c.Token == "" {
+			return nil, fmt.Errorf("%s: client %s has no token", path, name)
+		}
+		if tokens[c.Token] {
+			return nil, fmt.Errorf("%s: client %s has the token of another client", path, name)
+		}
+		tokens[c.Token] = true
+	}
+	return config.Clients, nil
+}
+
+// Allows reports whether the client may perform an operation
+func (c *GatewayClient) Allows(op Operation) bool {
+	selector := OperationSelector(op)
+	match := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			return MatchSelector(strings.TrimSpace(pattern), selector)
+		})
+	}
+	return (isReadOperation(op) && match(c.Read)) || match(c.Write)
+}
+
+type gatewayResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// NewGateway returns a gateway for the services
+func NewGateway(services ...Service) *Gateway {
+	g := &Gateway{
+		services: make(map[string]Service),
+		cache:    make(map[string]*gatewayResponse),
+	}
+	for _, s := range services {
+		g.services[s.Name()] = s
+	}
+	return g
+}
+
+// GatewayRoute returns the route of an operation on a gateway
+func GatewayRoute(op Operation) string {
+	r := op.Resource()
+	return "/" + url.PathEscape(r.Service().Name()) + "/" + url.PathEscape(r.Name()) + "/" + url.PathEscape(op.Name())
+}
+
+func (g *Gateway) allowed(client *GatewayClient, op Operation) bool {
+	return (g.Allow == nil || g.Allow(op)) && client.Allows(op)
+}
+
+// client returns the client with the bearer token of a request
+func (g *Gateway) client(r *http.Request) *GatewayClient {
+	for _, c := range g.Clients {
+		if c.Token != "" && hasBearerToken(r, c.Token) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkLocalRequest(r); err != nil {
+		writeGatewayError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	client := g.client(r)
+	if client == nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeGatewayError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 {
+		g.serveIndex(w, r, client, parts[0])
+		return
+	}
+	if len(parts) != 3 {
+		writeGatewayError(w, http.StatusNotFound, "routes are /<service>/<resource>/<operation>")
+		return
+	}
+	s, ok := g.services[parts[0]]
+	if !ok {
+		writeGatewayError(w, http.StatusNotFound, "unknown service: "+parts[0])
+		return
+	}
+	sel := Selector{Service: s.Name(), Resource: parts[1], Operation: parts[2]}
+	op, err := sel.ResolveOperation(s)
+	if err != nil {
+		writeGatewayError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if !g.allowed(client, op) {
+		writeGatewayError(w, http.StatusForbidden, OperationSelector(op)+" is not allowed")
+		return
+	}
+
+	read := isReadOperation(op)
+	switch {
+	case read && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+	case !read && (r.Method == http.MethodPost || strings.EqualFold(r.Method, op.Method())):
+	default:
+		allow := http.MethodPost
+		if read {
+			allow = http.MethodGet
+		}
+		w.Header().Set("Allow", allow)
+		writeGatewayError(w, http.StatusMethodNotAllowed, OperationSelector(op)+" is routed for "+allow)
+		return
+	}
+
+	limit := g.MaxBodySize
+	if limit == 0 {
+		limit = 10 << 20
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	in, err := gatewayInput(op, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeGatewayError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		writeGatewayError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var key string
+	if read && g.CacheTTL > 0 {
+		b, _ := json.Marshal(in)
+		key = OperationSelector(op) + " " + string(b)
+		if cached := g.cached(key); cached != nil {
+			cached.write(w, "HIT")
+			return
+		}
+	}
+
+	resp, err := g.perform(r.Context(), op, in)
+	if err != nil {
+		var perr *PolicyError
+		if errors.As(err, &perr) {
+			writeGatewayError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writeGatewayError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if key == "" {
+		resp.write(w, "")
+		return
+	}
+	if resp.status == http.StatusOK {
+		resp.expires = time.Now().Add(g.CacheTTL)
+		g.store(key, resp)
+	}
+	resp.write(w, "MISS")
+}
+
+// serveIndex lists the routes of the operations allowed for
+// a client, of every service or just the named one
+func (g *Gateway) serveIndex(w http.ResponseWriter, r *http.Request, client *GatewayClient, name string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		writeGatewayError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, ok := g.services[name]; name != "" && !ok {
+		writeGatewayError(w, http.StatusNotFound, "unknown service: "+name)
+		return
+	}
+	type route struct {
+		Route    string `json:"route"`
+		Method   string `json:"method"`
+		Selector string `json:"selector"`
+	}
+	routes := []route{}
+	for _, s := range g.services {
+		if name != "" && s.Name() != name {
+			continue
+		}
+		for _, res := range s.Resources() {
+			for _, op := range res.Operations() {
+				if !g.allowed(client, op) {
+					continue
+				}
+				method := http.MethodPost
+				if isReadOperation(op) {
+					method = http.MethodGet
+				}
+				routes = append(routes, route{
+					Route:    GatewayRoute(op),
+					Method:   method,
+					Selector: OperationSelector(op),
+				})
+			}
+		}
+	}
+	slices.SortFunc(routes, func(a, b route) int {
+		return strings.Compare(a.Route, b.Route)
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(routes)
+}
+
+// gatewayInput returns the input for an operation from a JSON body and
+// the query, typed by the parameters and inputs of the operation. Query
+// parameters repeated or given once for array inputs are lists.
+func gatewayInput(op Operation, r *http.Request) (map[string]any, error) {
+	in := map[string]any{}
+	if r.Body != nil && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(b))) > 0 {
+			if in, err = ParseInput(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	inputs := map[string]Schema{}
+	if input := op.Input(); input != nil {
+		for _, p := range input.Properties() {
+			inputs[p.Name()] = p
+		}
+	}
+	for _, p := range op.Parameters() {
+		inputs[p.Name()] = p
+	}
+	for k, v := range r.URL.Query() {
+		if _, exists := in[k]; exists {
+			continue
+		}
+		s := inputs[k]
+		if s != nil && effectiveType(s) == "array" {
+			in[k] = gatewayList(s, v)
+			continue
+		}
+		in[k] = v[0]
+		if s != nil {
+			if typed, ok := parseSchemaValue(s, v[0]); ok {
+				in[k] = typed
+			}
+		}
+	}
+	return in, nil
+}
+
+// gatewayList returns the list of an array input from query values,
+// which are items or a single JSON list
+func gatewayList(s Schema, values []string) []any {
+	if len(values) == 1 {
+		if typed, ok := parseSchemaValue(s, values[0]); ok {
+			if list, ok := typed.([]any); ok {
+				return list
+			}
+		}
+	}
+	items := s.Items()
+	list := make([]any, len(values))
+	for i, v := range values {
+		list[i] = v
+		if items != nil {
+			if typed, ok := parseSchemaValue(items, v); ok {
+				list[i] = typed
+			}
+		}
+	}
+	return list
+}
+
+// perform does an operation and reads its response
+func (g *Gateway) perform(ctx context.Context, op Operation, in map[string]any) (*gatewayResponse, error) {
+	resp, err := g.Do.orDefault()(ctx, op, in)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	for _, k := range gatewayHeaders {
+		if v := resp.Header.Values(k); len(v) > 0 {
+			header[k] = v
+		}
+	}
+	return &gatewayResponse{status: resp.StatusCode, header: header, body: body}, nil
+}
+
+func (g *Gateway) cached(key string) *gatewayResponse {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	resp, ok := g.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(resp.expires) {
+		delete(g.cache, key)
+		return nil
+	}
+	return resp
+}
+
+func (g *Gateway) store(key string, resp *gatewayResponse) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	for k, cached := range g.cache {
+		if now.After(cached.expires) {
+			delete(g.cache, k)
+		}
+	}
+	g.cache[key] = resp
+}
+
+func (resp *gatewayResponse) write(w http.ResponseWriter, cache string) {
+	for k, v := range resp.header {
+		w.Header()[k] = v
+	}
+	if cache != "" {
+		w.Header().Set("X-Cache", cache)
+	}
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+func writeGatewayError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
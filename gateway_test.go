@@ -0,0 +1,251 @@
+package integra
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGateway(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	var upstreamAuth []string
+	m := NewMock(s)
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAuth = append(upstreamAuth, r.Header.Get("Authorization"))
+		m.ServeHTTP(w, r)
+	}))
+	defer mock.Close()
+	s.SetBaseURL(mock.URL)
+
+	requests := 0
+	g := NewGateway(s)
+	g.CacheTTL = time.Minute
+	g.Allow = func(op Operation) bool {
+		return op.Name() != "delete"
+	}
+	g.Clients = map[string]*GatewayClient{
+		"app":    {Token: "app-token", Read: []string{"test"}, Write: []string{"test.pet.*"}},
+		"reader": {Token: "reader-token", Read: []string{"*"}, Write: []string{"test.pet.get"}},
+	}
+	client := NewClient()
+	client.Auth = TokenAuth("gateway-token")
+	g.Do = func(ctx context.Context, op Operation, in map[string]any) (*http.Response, error) {
+		requests++
+		return client.Do(ctx, op, in)
+	}
+	server := httptest.NewServer(g)
+	defer server.Close()
+
+	send := func(token, method, route, body string) (*http.Response, map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+route, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp, out
+	}
+	do := func(method, route, body string) (*http.Response, map[string]any) {
+		t.Helper()
+		return send("app-token", method, route, body)
+	}
+
+	resp, created := do("POST", "/test/pet/create", `{"name":"Fido"}`)
+	if resp.StatusCode != 201 || created["name"] != "Fido" {
+		t.Fatalf("create = %d %v", resp.StatusCode, created)
+	}
+
+	resp, got := do("GET", "/test/pet/get?pet_id=1", "")
+	if resp.StatusCode != 200 || got["name"] != "Fido" || resp.Header.Get("X-Cache") != "MISS" {
+		t.Errorf("get = %d %s %v", resp.StatusCode, resp.Header.Get("X-Cache"), got)
+	}
+	resp, got = do("GET", "/test/pets/get?pet_id=1", "")
+	if resp.StatusCode != 200 || got["name"] != "Fido" || resp.Header.Get("X-Cache") != "HIT" {
+		t.Errorf("cached get = %d %s %v", resp.StatusCode, resp.Header.Get("X-Cache"), got)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests to the service, got %d", requests)
+	}
+	if len(upstreamAuth) != 2 || upstreamAuth[0] != "Bearer gateway-token" || upstreamAuth[1] != "Bearer gateway-token" {
+		t.Errorf("expected gateway credentials upstream, got %v", upstreamAuth)
+	}
+
+	tests := []struct {
+		token  string
+		method string
+		route  string
+		status int
+	}{
+		{"app-token", "GET", "/test/pet/create", http.StatusMethodNotAllowed},
+		{"app-token", "POST", "/test/pet/get?pet_id=1", http.StatusMethodNotAllowed},
+		{"app-token", "DELETE", "/test/pet/delete?pet_id=1", http.StatusForbidden},
+		{"app-token", "GET", "/test/pet/gte", http.StatusNotFound},
+		{"app-token", "GET", "/other/pet/get", http.StatusNotFound},
+		{"", "GET", "/test/pet/get?pet_id=1", http.StatusUnauthorized},
+		{"wrong-token", "GET", "/test/pet/get?pet_id=1", http.StatusUnauthorized},
+		{"reader-token", "GET", "/test/pet/list", http.StatusOK},
+		{"reader-token", "POST", "/test/pet/create", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		if resp, _ := send(test.token, test.method, test.route, ""); resp.StatusCode != test.status {
+			t.Errorf("%s %s %s = %d; want %d", test.token, test.method, test.route, resp.StatusCode, test.status)
+		}
+	}
+
+	for _, header := range []map[string]string{
+		{"Host": "attacker.example"},
+		{"Origin": "https://attacker.example"},
+		{"Sec-Fetch-Site": "cross-site"},
+	} {
+		req := httptest.NewRequest("GET", "/test/pet/get?pet_id=1", nil)
+		req.Host = "localhost"
+		req.Header.Set("Authorization", "Bearer app-token")
+		for k, v := range header {
+			if k == "Host" {
+				req.Host = v
+			}
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%v = %d; want 403", header, rec.Code)
+		}
+	}
+
+	g.MaxBodySize = 8
+	if resp, _ := do("POST", "/test/pet/create", `{"name":"Fido"}`); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body = %d; want 413", resp.StatusCode)
+	}
+
+	index := func(token string) []map[string]string {
+		t.Helper()
+		resp, err := http.DefaultClient.Do(func() *http.Request {
+			req, _ := http.NewRequest("GET", server.URL+"/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req
+		}())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var routes []map[string]string
+		json.NewDecoder(resp.Body).Decode(&routes)
+		return routes
+	}
+	if routes := index("app-token"); len(routes) != 3 || routes[0]["route"] != "/test/pet/create" || routes[0]["method"] != "POST" {
+		t.Errorf("unexpected routes: %v", routes)
+	}
+	if routes := index("reader-token"); len(routes) != 2 || routes[0]["route"] != "/test/pet/get" {
+		t.Errorf("unexpected reader routes: %v", routes)
+	}
+}
+
+func TestGatewayInput(t *testing.T) {
+	s := loadTestOpenAPI(t, `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: ids
+          in: query
+          schema:
+            type: array
+            items:
+              type: integer
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: pets
+`)
+	op := testOperation(t, s, "pet", "list")
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"ids=1&ids=2&limit=5", `{"ids":[1,2],"limit":5}`},
+		{"ids=[1,2]", `{"ids":[1,2]}`},
+		{"tags=a", `{"tags":["a"]}`},
+		{"tags=a&tags=b", `{"tags":["a","b"]}`},
+	}
+	for _, test := range tests {
+		in, err := gatewayInput(op, httptest.NewRequest("GET", "/test/pet/list?"+test.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := json.Marshal(in); string(b) != test.want {
+			t.Errorf("%s = %s; want %s", test.query, b, test.want)
+		}
+	}
+}
+
+func TestLoadGatewayClients(t *testing.T) {
+	t.Setenv("WEB_GATEWAY_TOKEN", "web-token")
+	write := func(config string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "clients.yaml")
+		if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	clients, err := LoadGatewayClients(write(`
+clients:
+  web:
+    token: ${WEB_GATEWAY_TOKEN}
+    read: [test.pet.get]
+    write: [test.pet.create]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := clients["web"]
+	if web == nil || web.Token != "web-token" {
+		t.Fatalf("unexpected clients: %v", clients)
+	}
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	for op, want := range map[string]bool{"get": true, "create": true, "list": false, "delete": false} {
+		if got := web.Allows(testOperation(t, s, "pet", op)); got != want {
+			t.Errorf("web allows %s = %v; want %v", op, got, want)
+		}
+	}
+
+	for _, config := range []string{
+		"clients:\n  web:\n    token: ${MISSING_GATEWAY_TOKEN}\n",
+		"clients:\n  web:\n    token: same\n  worker:\n    token: same\n",
+		"clients:\n  web:\n    tokn: web-token\n",
+	} {
+		if _, err := LoadGatewayClients(write(config)); err == nil {
+			t.Errorf("expected error loading %q", config)
+		}
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/jinzhu/inflection v1.0.0
 	github.com/pb33f/libopenapi v0.18.5
 	github.com/progrium/clon-go v0.0.0-20221124010328-fe21965c77cb
+	golang.org/x/oauth2 v0.24.0
 	gopkg.in/yaml.v2 v2.4.0
 	tractor.dev/toolkit-go v0.0.0-20241010005851-214d91207d07
 )
@@ -19,6 +20,5 @@ require (
 	github.com/sergi/go-diff v1.3.1 // indirect
 	github.com/vmware-labs/yaml-jsonpath v0.3.2 // indirect
 	github.com/wk8/go-ordered-map/v2 v2.1.9-0.20240815153524-6ea36470d1bd // indirect
-	golang.org/x/oauth2 v0.24.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -12,9 +12,10 @@ import (
 )
 
 type googleService struct {
-	name   string
-	meta   *Value
-	schema *Value
+	name    string
+	meta    *Value
+	schema  *Value
+	baseURL string
 }
 
 func (s *googleService) Schema() *Value {
@@ -54,9 +55,31 @@ func (s *googleService) Categories() []string {
 }
 
 func (s *googleService) BaseURL() string {
+	if s.baseURL != "" {
+		return s.baseURL
+	}
 	return AsOrZero[string](s.schema.Get("baseUrl"))
 }
 
+func (s *googleService) SetBaseURL(u string) {
+	s.baseURL = u
+}
+
+func (s *googleService) Servers() []Server {
+	baseURL := AsOrZero[string](s.schema.Get("baseUrl"))
+	if baseURL == "" {
+		return nil
+	}
+	servers := []Server{{URL: baseURL}}
+	// mutual TLS endpoint is the root URL with the same service path
+	mtlsRoot := AsOrZero[string](s.schema.Get("mtlsRootUrl"))
+	if mtlsRoot != "" {
+		u, _ := url.JoinPath(mtlsRoot, AsOrZero[string](s.schema.Get("servicePath")))
+		servers = append(servers, Server{URL: u, Description: "mtls"})
+	}
+	return servers
+}
+
 func (s *googleService) DocsURL() string {
 	return AsOrZero[string](s.schema.Get("documentationLink"))
 }
@@ -100,6 +123,14 @@ func (s *googleService) Resource(name string) (Resource, error) {
 	return nil, fmt.Errorf("resource '%s' not found", name)
 }
 
+func (s *googleService) Webhooks() []Webhook {
+	return nil
+}
+
+func (s *googleService) Webhook(name string) (Webhook, error) {
+	return nil, fmt.Errorf("webhook '%s' not found", name)
+}
+
 type googleResource struct {
 	name    string
 	parent  *googleResource
@@ -116,6 +147,10 @@ func (r *googleResource) Service() Service {
 }
 
 func (r *googleResource) Parent() Resource {
+	if r.parent == nil {
+		// avoid a typed nil in the interface
+		return nil
+	}
 	return r.parent
 }
 
@@ -268,6 +303,10 @@ func (o *googleOperation) URL() string {
 	return u
 }
 
+func (o *googleOperation) Servers() []Server {
+	return o.resource.service.Servers()
+}
+
 func (o *googleOperation) Method() string {
 	return AsOrZero[string](o.schema.Get("httpMethod"))
 }
@@ -334,6 +373,18 @@ func (o *googleOperation) Response() Schema {
 	return resp
 }
 
+func (o *googleOperation) Responses() map[string]map[string]Schema {
+	resp := o.responseSchema()
+	if resp == nil {
+		// discovery doesn't say the status of methods without a
+		// response, which mostly reply 200 with an empty body
+		return map[string]map[string]Schema{"default": {}}
+	}
+	return map[string]map[string]Schema{
+		"200": {"application/json": resp},
+	}
+}
+
 func (o *googleOperation) Input() Schema {
 	reqRaw := o.schema.Get("request")
 	if reqRaw.IsNil() {
@@ -347,6 +398,14 @@ func (o *googleOperation) Input() Schema {
 	}
 }
 
+func (o *googleOperation) Inputs() map[string]Schema {
+	input := o.Input()
+	if input == nil {
+		return nil
+	}
+	return map[string]Schema{"application/json": input}
+}
+
 func (o *googleOperation) Output() Schema {
 	s, isListing := o.listingResponse()
 	if isListing {
@@ -458,6 +517,32 @@ func (p *googleParameter) Example() string {
 	return AsOrZero[string](p.schema.Get("example"))
 }
 
+func (p *googleParameter) Deprecated() bool {
+	return AsOrZero[bool](p.schema.Get("deprecated"))
+}
+
+func (p *googleParameter) Pattern() string {
+	return AsOrZero[string](p.schema.Get("pattern"))
+}
+
+func (p *googleParameter) Minimum() *float64 {
+	min := p.schema.Get("minimum")
+	if min.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](min)
+	return &v
+}
+
+func (p *googleParameter) Maximum() *float64 {
+	max := p.schema.Get("maximum")
+	if max.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](max)
+	return &v
+}
+
 type googleSchema struct {
 	name      string
 	schema    *Value
@@ -500,7 +585,7 @@ func (s *googleSchema) Format() string {
 }
 
 func (s *googleSchema) Default() string {
-	return AsOrZero[string](s.schema.Get("default"))
+	return schemaText(s.schema.Get("default"))
 }
 
 func (s *googleSchema) Nullable() bool {
@@ -508,15 +593,33 @@ func (s *googleSchema) Nullable() bool {
 }
 
 func (s *googleSchema) Example() string {
-	return AsOrZero[string](s.schema.Get("example"))
+	return schemaText(s.schema.Get("example"))
+}
+
+func (s *googleSchema) Deprecated() bool {
+	return AsOrZero[bool](s.schema.Get("deprecated"))
 }
 
-func (s *googleSchema) Minimum() *int {
+func (s *googleSchema) Pattern() string {
+	return AsOrZero[string](s.schema.Get("pattern"))
+}
+
+func (s *googleSchema) Minimum() *float64 {
+	// discovery documents use strings for numeric bounds
 	min := s.schema.Get("minimum")
 	if min.IsNil() {
 		return nil
 	}
-	v := AsOrZero[int](min)
+	v := AsOrZero[float64](min)
+	return &v
+}
+
+func (s *googleSchema) Maximum() *float64 {
+	max := s.schema.Get("maximum")
+	if max.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](max)
 	return &v
 }
 
@@ -593,3 +696,16 @@ func (s *googleSchema) Items() Schema {
 		schema:    items,
 	}
 }
+
+func (s *googleSchema) AdditionalProperties() Schema {
+	additional := s.schema.Get("additionalProperties")
+	if additional.IsNil() {
+		return nil
+	}
+	return &googleSchema{
+		name:      "(value)",
+		op:        s.op,
+		writeOnly: s.writeOnly,
+		schema:    additional,
+	}
+}
@@ -83,6 +83,9 @@ type Value struct {
 	data       interface{}
 	resolver   Resolver
 	mergeAllOf bool
+
+	// allOf are the members of an allOf directive merged into data
+	allOf []any
 }
 
 // New creates a new Value from any data
@@ -99,7 +102,8 @@ func (v *Value) WithResolver(resolver Resolver) *Value {
 	}
 }
 
-// WithAllOfMerge returns a new Value that merges down allOf directives
+// WithAllOfMerge returns a new Value that merges down allOf directives.
+// The merged members are still accessible with AllOf.
 func (v *Value) WithAllOfMerge() *Value {
 	return &Value{
 		data:       v.data,
@@ -112,14 +116,30 @@ func (v *Value) copyWithData(data any) *Value {
 	return &Value{data: data, resolver: v.resolver, mergeAllOf: v.mergeAllOf}
 }
 
-// resolve attempts to merge down allOf directives and resolve references
+// resolve attempts to resolve references and merge down allOf directives
 func (v *Value) resolve() (interface{}, error) {
-	resolved, err := v.resolveAllOf()
+	resolved, _, err := v.resolveMembers()
+	return resolved, err
+}
+
+// resolveMembers resolves a reference, which can point to a value with
+// an allOf directive, then merges down the allOf directive. The members
+// of the allOf directive are returned along with the merged value.
+func (v *Value) resolveMembers() (interface{}, []any, error) {
+	resolved, err := v.resolveRef()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	tmpValue := v.copyWithData(resolved)
-	return tmpValue.resolveRef()
+	members, _ := tmpValue.hasAllOf()
+	resolved, err = tmpValue.resolveAllOf()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !v.mergeAllOf {
+		members = nil
+	}
+	return resolved, members, nil
 }
 
 // hasRef checks if the current value is a reference object
@@ -168,32 +188,49 @@ func (v *Value) hasAllOf() ([]any, bool) {
 	return nil, false
 }
 
-// resolveAllOf attempts to merge down any objects under an allOf directive
+// resolveAllOf attempts to merge down any objects under an allOf directive.
+// The allOf directive itself is left out of the merged object, its members
+// are accessible with AllOf.
 func (v *Value) resolveAllOf() (interface{}, error) {
 	if vals, hasAllOf := v.hasAllOf(); hasAllOf && v.mergeAllOf {
 		var maps []map[string]any
 		for _, val := range vals {
-			tmpValue := v.copyWithData(val.(map[string]any))
-			innerResolve, err := tmpValue.resolve()
+			innerResolve, err := v.copyWithData(val).resolve()
 			if err != nil {
 				return nil, err
 			}
-			maps = append(maps, innerResolve.(map[string]any))
+			if m, ok := innerResolve.(map[string]any); ok {
+				maps = append(maps, m)
+			}
 		}
 		mergedMap := mergeMaps(maps...)
-		if m, ok := v.data.(map[string]any); ok {
-			for k, v := range m {
-				if k == "allOf" {
-					continue
-				}
-				mergedMap[k] = v
+		for k, val := range v.data.(map[string]any) {
+			if k == "allOf" {
+				continue
 			}
+			mergedMap[k] = val
 		}
 		return mergedMap, nil
 	}
 	return v.data, nil
 }
 
+// AllOf returns the members of an allOf directive merged down into the
+// value, or of the allOf directive of the value if it isn't merged
+func (v *Value) AllOf() (members []*Value) {
+	vals := v.allOf
+	if vals == nil {
+		_, vals, _ = v.resolveMembers()
+	}
+	if vals == nil {
+		vals, _ = v.hasAllOf()
+	}
+	for _, val := range vals {
+		members = append(members, v.copyWithData(val))
+	}
+	return
+}
+
 // Keys returns all keys of a map value in alphanumeric order.
 // Returns nil if the value is not a map.
 func (v *Value) Keys() (keys []string) {
@@ -235,10 +272,14 @@ func (v *Value) Items() (items []*Value) {
 // Keys can be string map keys or integer array indices
 func (v *Value) Get(keys ...interface{}) *Value {
 	current := v.data
+	members := v.allOf
 
 	// Try to resolve any reference at the start
-	if resolved, err := v.resolve(); err == nil {
+	if resolved, allOf, err := v.resolveMembers(); err == nil {
 		current = resolved
+		if allOf != nil {
+			members = allOf
+		}
 	} else {
 		log.Println(err)
 	}
@@ -266,14 +307,18 @@ func (v *Value) Get(keys ...interface{}) *Value {
 
 		// Try to resolve reference at each step
 		newValue := v.copyWithData(current)
-		if resolved, err := newValue.resolve(); err == nil {
+		members = nil
+		if resolved, allOf, err := newValue.resolveMembers(); err == nil {
 			current = resolved
+			members = allOf
 		} else {
 			log.Println(err)
 		}
 	}
 
-	return v.copyWithData(current)
+	value := v.copyWithData(current)
+	value.allOf = members
+	return value
 }
 
 // Data returns the raw underlying data
@@ -307,8 +352,10 @@ func As[T any](v *Value) (T, error) {
 		case []any:
 			var strs []string
 			for _, v := range val {
-				// todo: check to make sure v is string
-				strs = append(strs, v.(string))
+				if v == nil {
+					continue
+				}
+				strs = append(strs, fmt.Sprintf("%v", v))
 			}
 			return any(strs).(T), nil
 		}
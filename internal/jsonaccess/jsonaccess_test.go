@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -153,6 +154,17 @@ func TestTypeConversions(t *testing.T) {
 	}
 }
 
+func TestMixedListToStrings(t *testing.T) {
+	v := New(map[string]any{"enum": []any{"a", true, 2.5, nil}})
+	got, err := As[[]string](v.Get("enum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"a", "true", "2.5"}) {
+		t.Errorf("conversion = %v, want [a true 2.5]", got)
+	}
+}
+
 func TestPointerResolver(t *testing.T) {
 	jsonData := `{
 		"definitions": {
@@ -582,3 +594,83 @@ components:
 	}
 
 }
+
+func TestAllOfMembersKept(t *testing.T) {
+	testYaml := `object:
+  allOf:
+    - $ref: "#/components/schemas/base"
+    - properties:
+        name:
+          type: string
+components:
+  schemas:
+    base:
+      properties:
+        id:
+          type: integer
+`
+	var raw map[any]any
+	if err := yaml.Unmarshal([]byte(testYaml), &raw); err != nil {
+		t.Fatal(err)
+	}
+	data := convertYAMLToStringMap(raw)
+
+	root := New(data)
+	resolver := NewPointerResolver(root)
+	root = root.WithResolver(resolver).WithAllOfMerge()
+
+	result := root.Get("object", "properties").Keys()
+	expected := []string{"id", "name"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, result)
+	}
+
+	if keys := root.Get("object").Keys(); slices.Contains(keys, "allOf") {
+		t.Errorf("Expected allOf to be merged away, but got keys %+v", keys)
+	}
+
+	members := root.Get("object").AllOf()
+	if len(members) != 2 {
+		t.Fatalf("Expected 2 allOf members, but got %d", len(members))
+	}
+	result = members[0].Get("properties").Keys()
+	expected = []string{"id"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, result)
+	}
+}
+
+func TestAllOfRefToAllOf(t *testing.T) {
+	testYaml := `object:
+  allOf:
+    - $ref: "#/components/schemas/named"
+    - required: [name]
+components:
+  schemas:
+    named:
+      allOf:
+        - $ref: "#/components/schemas/base"
+        - properties:
+            name:
+              type: string
+    base:
+      properties:
+        id:
+          type: integer
+`
+	var raw map[any]any
+	if err := yaml.Unmarshal([]byte(testYaml), &raw); err != nil {
+		t.Fatal(err)
+	}
+	data := convertYAMLToStringMap(raw)
+
+	root := New(data)
+	resolver := NewPointerResolver(root)
+	root = root.WithResolver(resolver).WithAllOfMerge()
+
+	result := root.Get("object", "properties").Keys()
+	expected := []string{"id", "name"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, result)
+	}
+}
@@ -0,0 +1,147 @@
+package jsonaccess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Query evaluates a path expression against the value. The expression is a
+// subset shared by jq and JSONPath: an optional leading "$" or ".", then
+// segments of ".key", "[\"key\"]", "[index]" (negative counts from the end),
+// and "[]", "[*]" or ".*" to iterate over list items or map values. Once an
+// expression iterates, the remaining segments apply to each element and the
+// results are collected into a list.
+//
+//	v.Query(".droplets[].name")
+//	v.Query("$.items[0].id")
+func (v *Value) Query(expr string) (*Value, error) {
+	segments, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	current := []*Value{v}
+	iterated := false
+	for _, seg := range segments {
+		var next []*Value
+		for _, c := range current {
+			switch {
+			case seg.iterate:
+				if items := c.Items(); items != nil {
+					next = append(next, items...)
+					continue
+				}
+				for _, k := range c.Keys() {
+					next = append(next, c.Get(k))
+				}
+			case seg.index != nil:
+				idx := *seg.index
+				if idx < 0 {
+					if s, ok := c.data.([]any); ok {
+						idx += len(s)
+					}
+				}
+				next = append(next, c.Get(idx))
+			default:
+				next = append(next, c.Get(seg.key))
+			}
+		}
+		if seg.iterate {
+			iterated = true
+		}
+		current = next
+	}
+	if !iterated {
+		return current[0], nil
+	}
+	results := make([]any, len(current))
+	for i, c := range current {
+		results[i] = c.data
+	}
+	return v.copyWithData(results), nil
+}
+
+type querySegment struct {
+	key     string
+	index   *int
+	iterate bool
+}
+
+func parseQuery(expr string) (segments []querySegment, err error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	if s == "." {
+		return nil, nil
+	}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if strings.HasPrefix(s, "*") {
+				segments = append(segments, querySegment{iterate: true})
+				s = s[1:]
+				continue
+			}
+			if strings.HasPrefix(s, "[") || s == "" {
+				continue
+			}
+			if s[0] == '"' {
+				key, rest, err := parseQuoted(s)
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, querySegment{key: key})
+				s = rest
+				continue
+			}
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			segments = append(segments, querySegment{key: s[:end]})
+			s = s[end:]
+		case '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in query: %s", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			switch {
+			case inner == "" || inner == "*":
+				segments = append(segments, querySegment{iterate: true})
+				s = s[end+1:]
+			case inner[0] == '"' || inner[0] == '\'':
+				key, rest, err := parseQuoted(strings.TrimSpace(s[1:]))
+				if err != nil {
+					return nil, err
+				}
+				rest = strings.TrimSpace(rest)
+				if !strings.HasPrefix(rest, "]") {
+					return nil, fmt.Errorf("expected ] after key in query: %s", expr)
+				}
+				segments = append(segments, querySegment{key: key})
+				s = rest[1:]
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in query: %s", inner, expr)
+				}
+				segments = append(segments, querySegment{index: &idx})
+				s = s[end+1:]
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in query: %s", s[0], expr)
+		}
+	}
+	return segments, nil
+}
+
+// parseQuoted parses a single or double quoted key at the start of s
+func parseQuoted(s string) (key, rest string, err error) {
+	quote := s[0]
+	end := strings.IndexByte(s[1:], quote)
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated quote in query: %s", s)
+	}
+	return s[1 : end+1], s[end+2:], nil
+}
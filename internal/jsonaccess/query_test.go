@@ -0,0 +1,53 @@
+package jsonaccess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	data := map[string]any{
+		"meta": map[string]any{"total": float64(2)},
+		"droplets": []any{
+			map[string]any{"id": float64(1), "name": "web", "tags": []any{"a", "b"}},
+			map[string]any{"id": float64(2), "name": "db", "tags": []any{"c"}},
+		},
+		"links.pages": map[string]any{"next": "/v2/droplets?page=2"},
+	}
+	v := New(data)
+
+	tests := []struct {
+		query    string
+		expected any
+	}{
+		{".", data},
+		{".meta.total", float64(2)},
+		{"$.meta.total", float64(2)},
+		{".droplets[0].name", "web"},
+		{".droplets[-1].name", "db"},
+		{".droplets[].name", []any{"web", "db"}},
+		{"$.droplets[*].id", []any{float64(1), float64(2)}},
+		{".droplets[].tags[]", []any{"a", "b", "c"}},
+		{`.["links.pages"].next`, "/v2/droplets?page=2"},
+		{`."links.pages".next`, "/v2/droplets?page=2"},
+		{".meta.*", []any{float64(2)}},
+		{".missing.key", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			result, err := v.Query(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Data(), test.expected) {
+				t.Errorf("Query(%q) = %v; want %v", test.query, result.Data(), test.expected)
+			}
+		})
+	}
+
+	for _, query := range []string{".droplets[0", ".droplets[x]", "droplets"} {
+		if _, err := v.Query(query); err == nil {
+			t.Errorf("Query(%q): expected error", query)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package integra
+
+// jsonSchemaMaxDepth limits converted schemas of recursive types
+const jsonSchemaMaxDepth = 8
+
+// JSONSchema converts a schema to a JSON Schema document, for
+// tools that describe their input or output with JSON Schema
+func JSONSchema(s Schema) map[string]any {
+	return jsonSchema(s, 0)
+}
+
+func jsonSchema(s Schema, depth int) map[string]any {
+	js := map[string]any{}
+	if s == nil || depth > jsonSchemaMaxDepth {
+		return js
+	}
+	if t := effectiveType(s); t != "" {
+		js["type"] = t
+		if s.Nullable() {
+			js["type"] = []any{t, "null"}
+		}
+	}
+	if d := s.Description(); d != "" {
+		js["description"] = d
+	} else if t := s.Title(); t != "" {
+		js["description"] = t
+	}
+	if f := s.Format(); f != "" {
+		js["format"] = f
+	}
+	if p := s.Pattern(); p != "" {
+		js["pattern"] = p
+	}
+	if enum := s.Enum(); len(enum) > 0 {
+		var values []any
+		for _, e := range enum {
+			if v, ok := parseEnumValue(s, e); ok {
+				values = append(values, v)
+			}
+		}
+		js["enum"] = values
+	}
+	if d := s.Default(); d != "" {
+		if v, ok := parseSchemaValue(s, d); ok {
+			js["default"] = v
+		}
+	}
+	if s.Deprecated() {
+		js["deprecated"] = true
+	}
+	if s.ReadOnly() {
+		js["readOnly"] = true
+	}
+	if v := s.Minimum(); v != nil {
+		js["minimum"] = *v
+	}
+	if v := s.Maximum(); v != nil {
+		js["maximum"] = *v
+	}
+	if v := s.MultipleOf(); v != nil {
+		js["multipleOf"] = *v
+	}
+	if v := s.MinLength(); v != nil {
+		js["minLength"] = *v
+	}
+	if v := s.MaxLength(); v != nil {
+		js["maxLength"] = *v
+	}
+	if v := s.MinItems(); v != nil {
+		js["minItems"] = *v
+	}
+	if v := s.MaxItems(); v != nil {
+		js["maxItems"] = *v
+	}
+	if s.UniqueItems() {
+		js["uniqueItems"] = true
+	}
+	for keyword, variants := range map[string][]Schema{"oneOf": s.OneOf(), "anyOf": s.AnyOf()} {
+		if len(variants) == 0 {
+			continue
+		}
+		var list []any
+		for _, v := range variants {
+			list = append(list, jsonSchema(v, depth+1))
+		}
+		js[keyword] = list
+	}
+	if items := s.Items(); items != nil {
+		js["items"] = jsonSchema(items, depth+1)
+	}
+	if props := s.Properties(); len(props) > 0 {
+		properties := map[string]any{}
+		var required []any
+		for _, prop := range props {
+			properties[prop.Name()] = jsonSchema(prop, depth+1)
+			if prop.Required() {
+				required = append(required, prop.Name())
+			}
+		}
+		js["properties"] = properties
+		if len(required) > 0 {
+			js["required"] = required
+		}
+	}
+	if extra := s.AdditionalProperties(); extra != nil {
+		js["additionalProperties"] = jsonSchema(extra, depth+1)
+	}
+	return js
+}
+
+// OperationInputSchema returns a JSON Schema object for the input of an
+// operation, with parameters and body properties side by side as they
+// are given to MakeRequest
+func OperationInputSchema(op Operation) map[string]any {
+	properties := map[string]any{}
+	var required []any
+	for _, param := range op.Parameters() {
+		properties[param.Name()] = jsonSchema(param, 1)
+		if param.Required() {
+			required = append(required, param.Name())
+		}
+	}
+	if input := op.Input(); input != nil {
+		for _, prop := range input.Properties() {
+			if _, exists := properties[prop.Name()]; exists {
+				continue
+			}
+			properties[prop.Name()] = jsonSchema(prop, 1)
+			if prop.Required() {
+				required = append(required, prop.Name())
+			}
+		}
+	}
+	js := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		js["required"] = required
+	}
+	return js
+}
@@ -0,0 +1,87 @@
+package integra
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LocalGuard protects a handler serving credentials on a loopback
+// address. Requests must have the bearer token, and a loopback Host
+// header, which stops DNS rebinding. Requests from browsers must come
+// from a loopback origin, which stops other sites posting to it.
+type LocalGuard struct {
+	Handler http.Handler
+	Token   string
+}
+
+// NewLocalToken returns a random token for a LocalGuard
+func NewLocalToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// IsLoopbackAddr reports whether an address to listen on,
+// like localhost:8080, is only reachable from this machine
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return isLoopbackHost(host)
+}
+
+func isLoopbackHost(host string) bool {
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (g *LocalGuard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkLocalRequest(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if g.Token == "" || !hasBearerToken(r, g.Token) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+		return
+	}
+	g.Handler.ServeHTTP(w, r)
+}
+
+// checkLocalRequest returns an error unless a request is for a
+// loopback host and, from browsers, from a loopback origin
+func checkLocalRequest(r *http.Request) error {
+	if !IsLoopbackAddr(r.Host) {
+		return fmt.Errorf("unexpected host: %s", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !isLoopbackHost(u.Hostname()) {
+			return errors.New("cross-origin requests are not allowed")
+		}
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return errors.New("cross-site requests are not allowed")
+	}
+	return nil
+}
+
+// hasBearerToken reports whether a request has the bearer token
+func hasBearerToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,52 @@
+package integra
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocalGuard(t *testing.T) {
+	guard := &LocalGuard{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		Token:   "secret",
+	}
+	tests := []struct {
+		host   string
+		header map[string]string
+		status int
+	}{
+		{"localhost:8080", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"127.0.0.1:8080", map[string]string{"Authorization": "Bearer secret", "Origin": "http://localhost:3000"}, http.StatusOK},
+		{"localhost:8080", nil, http.StatusUnauthorized},
+		{"localhost:8080", map[string]string{"Authorization": "Bearer wrong"}, http.StatusUnauthorized},
+		{"attacker.example:8080", map[string]string{"Authorization": "Bearer secret"}, http.StatusForbidden},
+		{"localhost:8080", map[string]string{"Authorization": "Bearer secret", "Origin": "https://attacker.example"}, http.StatusForbidden},
+		{"localhost:8080", map[string]string{"Authorization": "Bearer secret", "Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Host = test.host
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		guard.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s %v = %d; want %d", test.host, test.header, rec.Code, test.status)
+		}
+	}
+
+	for addr, want := range map[string]bool{
+		"localhost:8080": true,
+		"127.0.0.1:80":   true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.0.0.2:8080":  false,
+	} {
+		if got := IsLoopbackAddr(addr); got != want {
+			t.Errorf("IsLoopbackAddr(%q) = %v; want %v", addr, got, want)
+		}
+	}
+}
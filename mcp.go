@@ -0,0 +1,405 @@
+package integra
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// mcpProtocolVersions are the Model Context Protocol versions
+// supported, with the latest last
+var mcpProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// MCPServer serves services over the Model Context Protocol. Reads of
+// items at the ItemURLs of resources are exposed as resources, using
+// the item URLs as URIs and URI templates, so relative items like the
+// authenticated user are listed directly. Other operations, including
+// listing operations, are exposed as tools.
+type MCPServer struct {
+	Name    string
+	Version string
+
+	// Allow reports whether an operation is exposed. All
+	// operations are exposed if Allow is nil.
+	Allow func(op Operation) bool
+
+	// ReadTools also exposes item reads as tools, so
+	// they can be called with query parameters
+	ReadTools bool
+
+	// Do performs operations, see DoFunc
+	Do DoFunc
+
+	services []Service
+
+	once      sync.Once
+	tools     map[string]Operation
+	toolNames []string
+	readers   []*mcpReader
+}
+
+type mcpReader struct {
+	op      Operation
+	re      *regexp.Regexp
+	params  []string
+	literal int
+}
+
+// NewMCPServer returns an MCP server for the services
+func NewMCPServer(services ...Service) *MCPServer {
+	return &MCPServer{
+		Name:     "integra",
+		Version:  "dev",
+		services: services,
+	}
+}
+
+// OperationSelector returns the selector for an operation, like github.issue.get
+func OperationSelector(op Operation) string {
+	r := op.Resource()
+	return fmt.Sprintf("%s.%s.%s", r.Service().Name(), r.Name(), op.Name())
+}
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// mcpToolName returns a tool name for an operation, which
+// may only use letters, numbers, underscores and hyphens
+func mcpToolName(op Operation) string {
+	name := strings.ReplaceAll(OperationSelector(op), "~", "_rel")
+	name = invalidToolNameChars.ReplaceAllString(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+func isReadOperation(op Operation) bool {
+	return strings.EqualFold(op.Method(), http.MethodGet)
+}
+
+// isItemRead reports whether an operation reads an item
+// at one of the item URLs of its resource
+func isItemRead(op Operation) bool {
+	return isReadOperation(op) && slices.Contains(op.Resource().ItemURLs(), op.URL())
+}
+
+func (m *MCPServer) init() {
+	m.once.Do(func() {
+		m.tools = make(map[string]Operation)
+		for _, s := range m.services {
+			for _, r := range s.Resources() {
+				for _, op := range r.Operations() {
+					if m.Allow != nil && !m.Allow(op) {
+						continue
+					}
+					if isItemRead(op) {
+						re, params, literal := templatePattern(op.URL())
+						m.readers = append(m.readers, &mcpReader{op: op, re: re, params: params, literal: literal})
+						if !m.ReadTools {
+							continue
+						}
+					}
+					name := mcpToolName(op)
+					if _, exists := m.tools[name]; exists {
+						continue
+					}
+					m.tools[name] = op
+					m.toolNames = append(m.toolNames, name)
+				}
+			}
+		}
+		slices.SortStableFunc(m.readers, func(a, b *mcpReader) int {
+			return b.literal - a.literal
+		})
+	})
+}
+
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *mcpError) Error() string {
+	return e.Message
+}
+
+// JSON-RPC error codes
+const (
+	mcpParseError     = -32700
+	mcpMethodNotFound = -32601
+	mcpInvalidParams  = -32602
+	mcpInternalError  = -32603
+)
+
+// Handle handles a JSON-RPC message and returns the response
+// to send back, which is nil for notifications. Operations are
+// performed with ctx.
+func (m *MCPServer) Handle(ctx context.Context, msg []byte) []byte {
+	m.init()
+	var req mcpRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		b, _ := json.Marshal(mcpResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &mcpError{Code: mcpParseError, Message: err.Error()},
+		})
+		return b
+	}
+	result, err := m.call(ctx, req.Method, req.Params)
+	if req.ID == nil {
+		// notifications get no response
+		return nil
+	}
+	resp := mcpResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		rpcErr, ok := err.(*mcpError)
+		if !ok {
+			rpcErr = &mcpError{Code: mcpInternalError, Message: err.Error()}
+		}
+		resp.Result = nil
+		resp.Error = rpcErr
+	}
+	b, _ := json.Marshal(resp)
+	return b
+}
+
+func (m *MCPServer) call(ctx context.Context, method string, rawParams json.RawMessage) (any, error) {
+	var params struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		Name            string         `json:"name"`
+		Arguments       map[string]any `json:"arguments"`
+		URI             string         `json:"uri"`
+	}
+	if len(rawParams) > 0 {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, &mcpError{Code: mcpInvalidParams, Message: err.Error()}
+		}
+	}
+
+	switch method {
+	case "initialize":
+		version := mcpProtocolVersions[len(mcpProtocolVersions)-1]
+		if slices.Contains(mcpProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+			},
+			"serverInfo": map[string]any{
+				"name":    m.Name,
+				"version": m.Version,
+			},
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		tools := []any{}
+		for _, name := range m.toolNames {
+			op := m.tools[name]
+			tools = append(tools, map[string]any{
+				"name":        name,
+				"description": mcpDescription(op),
+				"inputSchema": OperationInputSchema(op),
+			})
+		}
+		return map[string]any{"tools": tools}, nil
+
+	case "tools/call":
+		op, ok := m.tools[params.Name]
+		if !ok {
+			return nil, &mcpError{Code: mcpInvalidParams, Message: "unknown tool: " + params.Name}
+		}
+		if params.Arguments == nil {
+			params.Arguments = map[string]any{}
+		}
+		text, isError := m.perform(ctx, op, params.Arguments)
+		return map[string]any{
+			"content": []any{map[string]any{"type": "text", "text": text}},
+			"isError": isError,
+		}, nil
+
+	case "resources/list":
+		resources := []any{}
+		for _, reader := range m.readers {
+			if len(reader.params) > 0 || len(RequiredParameters(reader.op)) > 0 {
+				continue
+			}
+			resources = append(resources, map[string]any{
+				"uri":         reader.op.URL(),
+				"name":        OperationSelector(reader.op),
+				"description": mcpDescription(reader.op),
+				"mimeType":    "application/json",
+			})
+		}
+		return map[string]any{"resources": resources}, nil
+
+	case "resources/templates/list":
+		templates := []any{}
+		for _, reader := range m.readers {
+			if len(reader.params) == 0 {
+				continue
+			}
+			templates = append(templates, map[string]any{
+				"uriTemplate": reader.op.URL(),
+				"name":        OperationSelector(reader.op),
+				"description": mcpDescription(reader.op),
+				"mimeType":    "application/json",
+			})
+		}
+		return map[string]any{"resourceTemplates": templates}, nil
+
+	case "resources/read":
+		op, in, err := m.matchResource(params.URI)
+		if err != nil {
+			return nil, &mcpError{Code: mcpInvalidParams, Message: err.Error()}
+		}
+		text, isError := m.perform(ctx, op, in)
+		if isError {
+			return nil, &mcpError{Code: mcpInternalError, Message: text}
+		}
+		return map[string]any{
+			"contents": []any{map[string]any{
+				"uri":      params.URI,
+				"mimeType": "application/json",
+				"text":     text,
+			}},
+		}, nil
+
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+
+	default:
+		return nil, &mcpError{Code: mcpMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// matchResource finds the read operation for a resource URI
+// and the input given by its path and query parameters
+func (m *MCPServer) matchResource(uri string) (Operation, map[string]any, error) {
+	base, query, _ := strings.Cut(uri, "?")
+	for _, reader := range m.readers {
+		match := reader.re.FindStringSubmatch(base)
+		if match == nil {
+			continue
+		}
+		in := map[string]any{}
+		for i, v := range match[1:] {
+			in[reader.params[i]], _ = url.PathUnescape(v)
+		}
+		values, _ := url.ParseQuery(query)
+		for k, v := range values {
+			in[k] = v[0]
+		}
+		return reader.op, in, nil
+	}
+	return nil, nil, fmt.Errorf("unknown resource: %s", uri)
+}
+
+// perform does an operation and returns the response body as text,
+// reporting non-success responses and request errors as errors
+func (m *MCPServer) perform(ctx context.Context, op Operation, in map[string]any) (string, bool) {
+	resp, err := m.Do.orDefault()(ctx, op, in)
+	if err != nil {
+		return err.Error(), true
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err.Error(), true
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" || IsJSONMediaType(mediaType) {
+		var buf bytes.Buffer
+		if json.Indent(&buf, body, "", "  ") == nil {
+			body = buf.Bytes()
+		}
+	}
+	if resp.StatusCode > 299 {
+		return fmt.Sprintf("%s\n%s", resp.Status, body), true
+	}
+	if len(body) == 0 {
+		return resp.Status, false
+	}
+	return string(body), false
+}
+
+func mcpDescription(op Operation) string {
+	desc := op.Description()
+	if desc == "" {
+		desc = OperationSelector(op)
+	}
+	if url := op.DocsURL(); url != "" {
+		desc += "\n\nDocs: " + url
+	}
+	return desc
+}
+
+// ServeStdio serves newline delimited JSON-RPC messages read from r,
+// writing responses to w, until r is closed
+func (m *MCPServer) ServeStdio(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if resp := m.Handle(context.Background(), line); resp != nil {
+			if _, err := fmt.Fprintf(w, "%s\n", resp); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeHTTP serves the streamable HTTP transport, answering
+// each posted JSON-RPC message with a JSON response
+func (m *MCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	msg, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := m.Handle(r.Context(), msg)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
@@ -0,0 +1,94 @@
+package integra
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMCPServer(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	server := httptest.NewServer(NewMock(s))
+	defer server.Close()
+	s.SetBaseURL(server.URL)
+
+	m := NewMCPServer(s)
+	m.Allow = func(op Operation) bool {
+		return op.Name() != "delete"
+	}
+
+	var out strings.Builder
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"test_pet_create","arguments":{"name":"Fido"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/templates/list"}`,
+		`{"jsonrpc":"2.0","id":6,"method":"resources/read","params":{"uri":"` + server.URL + `/pets/1"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"test_pet_delete"}}`,
+	}, "\n")
+	if err := m.ServeStdio(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 responses, got %d:\n%s", len(lines), out.String())
+	}
+	var responses []map[string]any
+	for _, line := range lines {
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	result := func(i int) map[string]any {
+		r, _ := responses[i]["result"].(map[string]any)
+		return r
+	}
+
+	if v := result(0)["protocolVersion"]; v != "2025-03-26" {
+		t.Errorf("protocolVersion = %v", v)
+	}
+
+	tools := result(1)["tools"].([]any)
+	var toolNames []string
+	for _, tool := range tools {
+		toolNames = append(toolNames, tool.(map[string]any)["name"].(string))
+	}
+	if strings.Join(toolNames, ",") != "test_pet_list,test_pet_create" {
+		t.Fatalf("expected list and create tools, got %v", toolNames)
+	}
+	tool := tools[1].(map[string]any)
+	props := tool["inputSchema"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := props["name"]; !ok {
+		t.Errorf("tool input schema missing body property: %v", props)
+	}
+
+	content := result(2)["content"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(content, `"name": "Fido"`) || result(2)["isError"] != false {
+		t.Errorf("unexpected tool result: %v", result(2))
+	}
+
+	// listing pets is a tool, so only the item URL is a resource
+	resources := result(3)["resources"].([]any)
+	if len(resources) != 0 {
+		t.Errorf("unexpected resources: %v", resources)
+	}
+	templates := result(4)["resourceTemplates"].([]any)
+	if len(templates) != 1 || templates[0].(map[string]any)["uriTemplate"] != server.URL+"/pets/{pet_id}" {
+		t.Errorf("unexpected resource templates: %v", templates)
+	}
+
+	contents := result(5)["contents"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(contents, `"name": "Fido"`) {
+		t.Errorf("unexpected resource contents: %v", contents)
+	}
+
+	if responses[6]["error"] == nil {
+		t.Errorf("expected error calling tool that isn't allowed")
+	}
+}
@@ -0,0 +1,397 @@
+package integra
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// mockBaseURL is the base URL set on a mocked service
+// so operation URLs can be routed by path
+const mockBaseURL = "http://mock"
+
+// Mock is an http.Handler implementing every operation of a service.
+// Responses are synthesized from the response schemas, and an in-memory
+// store makes create, get, list, update and delete on a resource
+// behave consistently with each other.
+type Mock struct {
+	service Service
+	routes  []*mockRoute
+
+	mu    sync.Mutex
+	store map[string][]*mockItem
+	seq   int
+}
+
+type mockRoute struct {
+	op     Operation
+	method string
+	re     *regexp.Regexp
+	params []string
+	isItem bool
+
+	// literal is the length of the non-parameter part of the
+	// path, used to prefer specific routes over generic ones
+	literal int
+}
+
+type mockItem struct {
+	id   string
+	data map[string]any
+}
+
+var pathParamPattern = regexp.MustCompile(`\{\+?([^}]+)\}`)
+
+// NewMock returns a mock for the service. The base URL of the service is
+// replaced so routes only depend on operation paths, which means requests
+// can be made to the mock by setting the base URL to wherever it's served.
+func NewMock(s Service) *Mock {
+	s.SetBaseURL(mockBaseURL)
+	m := &Mock{
+		service: s,
+		store:   make(map[string][]*mockItem),
+	}
+	for _, r := range s.Resources() {
+		for _, op := range r.Operations() {
+			if route := newMockRoute(op); route != nil {
+				m.routes = append(m.routes, route)
+			}
+		}
+	}
+	slices.SortStableFunc(m.routes, func(a, b *mockRoute) int {
+		return b.literal - a.literal
+	})
+	return m
+}
+
+func newMockRoute(op Operation) *mockRoute {
+	u, err := url.Parse(strings.ReplaceAll(strings.ReplaceAll(op.URL(), "{", "%7B"), "}", "%7D"))
+	if err != nil || op.Method() == "" {
+		return nil
+	}
+	path := "/" + strings.TrimPrefix(u.Path, "/")
+	route := &mockRoute{
+		op:     op,
+		method: strings.ToUpper(op.Method()),
+		isItem: strings.HasSuffix(path, "}"),
+	}
+	route.re, route.params, route.literal = templatePattern(path)
+	return route
+}
+
+// templatePattern compiles a URL template into a regular expression
+// matching its parameters, also returning the parameter names and
+// the length of the literal parts of the template
+func templatePattern(template string) (re *regexp.Regexp, params []string, literal int) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range pathParamPattern.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		literal += loc[0] - last
+		if strings.HasPrefix(template[loc[0]:], "{+") {
+			// reserved expansion can span segments
+			pattern.WriteString("(.+)")
+		} else {
+			pattern.WriteString("([^/]+)")
+		}
+		params = append(params, template[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	literal += len(template) - last
+	pattern.WriteString("/?$")
+	return regexp.MustCompile(pattern.String()), params, literal
+}
+
+func (m *Mock) match(r *http.Request) (*mockRoute, []string) {
+	for _, route := range m.routes {
+		if route.method != r.Method {
+			continue
+		}
+		if match := route.re.FindStringSubmatch(r.URL.Path); match != nil {
+			values := make([]string, len(match)-1)
+			for i, v := range match[1:] {
+				values[i], _ = url.PathUnescape(v)
+			}
+			return route, values
+		}
+	}
+	return nil, nil
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, values := m.match(r)
+	if route == nil {
+		writeMockJSON(w, http.StatusNotFound, map[string]any{"message": "no operation for " + r.Method + " " + r.URL.Path})
+		return
+	}
+	op := route.op
+	status := mockStatus(op)
+
+	body := map[string]any{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		collection string
+		id         string
+	)
+	if route.isItem {
+		collection = mockCollection(op, values[:len(values)-1])
+		id = values[len(values)-1]
+	} else {
+		collection = mockCollection(op, values)
+	}
+
+	switch {
+	case route.isItem && r.Method == http.MethodGet:
+		item := m.find(collection, id)
+		if item == nil {
+			writeMockJSON(w, http.StatusNotFound, map[string]any{"message": "not found"})
+			return
+		}
+		writeMockJSON(w, status, mockWrap(op, item.data))
+
+	case route.isItem && (r.Method == http.MethodPut || r.Method == http.MethodPatch):
+		item := m.find(collection, id)
+		if item == nil {
+			item = m.insert(op, route, collection, id, body)
+		}
+		for k, v := range body {
+			item.data[k] = v
+		}
+		writeMockJSON(w, status, mockWrap(op, item.data))
+
+	case route.isItem && r.Method == http.MethodDelete:
+		if m.find(collection, id) == nil {
+			writeMockJSON(w, http.StatusNotFound, map[string]any{"message": "not found"})
+			return
+		}
+		m.store[collection] = slices.DeleteFunc(m.store[collection], func(i *mockItem) bool {
+			return i.id == id
+		})
+		writeMockJSON(w, status, mockWrap(op, nil))
+
+	case !route.isItem && r.Method == http.MethodGet && isListing(op):
+		items := []any{}
+		for _, item := range m.store[collection] {
+			items = append(items, item.data)
+		}
+		writeMockJSON(w, status, mockWrap(op, items))
+
+	case !route.isItem && r.Method == http.MethodPost:
+		item := m.insert(op, route, collection, "", body)
+		writeMockJSON(w, status, mockWrap(op, item.data))
+
+	default:
+		writeMockJSON(w, status, mockWrap(op, nil))
+	}
+}
+
+// find returns the stored item with id in a collection
+func (m *Mock) find(collection, id string) *mockItem {
+	for _, item := range m.store[collection] {
+		if item.id == id {
+			return item
+		}
+	}
+	return nil
+}
+
+// insert stores a new item made from the output schema of
+// the operation and the request body. Without an id, one
+// is assigned to the "id" property or item path parameter.
+func (m *Mock) insert(op Operation, route *mockRoute, collection, id string, body map[string]any) *mockItem {
+	data, _ := mockValue(mockItemSchema(op), 0).(map[string]any)
+	if data == nil {
+		data = map[string]any{}
+	}
+	for k, v := range body {
+		data[k] = v
+	}
+	idProp := "id"
+	if _, ok := data[idProp]; !ok && route.isItem {
+		idProp = route.params[len(route.params)-1]
+	}
+	if id == "" {
+		if v, ok := body[idProp]; ok {
+			id = formString(v)
+		} else {
+			m.seq++
+			id = strconv.Itoa(m.seq)
+			if _, isNum := data[idProp].(float64); isNum {
+				data[idProp] = float64(m.seq)
+			} else {
+				data[idProp] = id
+			}
+		}
+	} else if v, ok := data[idProp]; ok {
+		data[idProp] = id
+		if _, isNum := v.(float64); isNum {
+			if n, err := strconv.ParseFloat(id, 64); err == nil {
+				data[idProp] = n
+			}
+		}
+	}
+	item := &mockItem{id: id, data: data}
+	m.store[collection] = append(m.store[collection], item)
+	return item
+}
+
+// mockCollection returns the store key for items of the operation's
+// resource under the given parent path parameters
+func mockCollection(op Operation, parents []string) string {
+	return strings.Join(append([]string{op.Resource().Name()}, parents...), "/")
+}
+
+func isListing(op Operation) bool {
+	out := op.Output()
+	return out != nil && out.Type() == "array"
+}
+
+// mockStatus returns the lowest success status declared by the operation
+func mockStatus(op Operation) int {
+	status := 0
+	for code := range op.Responses() {
+		n, err := strconv.Atoi(strings.ReplaceAll(strings.ToUpper(code), "XX", "00"))
+		if err != nil || n < 200 || n > 299 {
+			continue
+		}
+		if status == 0 || n < status {
+			status = n
+		}
+	}
+	if status == 0 {
+		return http.StatusOK
+	}
+	return status
+}
+
+// mockWrap builds the response for an operation with its output
+// replaced by data, putting it in the response envelope if the
+// response wraps its output. A nil data synthesizes the output.
+func mockWrap(op Operation, data any) any {
+	resp, out := op.Response(), op.Output()
+	if resp == nil {
+		return nil
+	}
+	if data == nil {
+		return mockValue(resp, 0)
+	}
+	envelope, ok := mockValue(resp, 0).(map[string]any)
+	if !ok {
+		return data
+	}
+	if out != nil && resp.Name() != out.Name() {
+		envelope[out.Name()] = data
+		return envelope
+	}
+	// responses like a oneOf can wrap an item without it being
+	// detected as the output, so look for it by resource name
+	for _, name := range NameVariants(op.Resource().Name()) {
+		if _, ok := envelope[name].(map[string]any); ok {
+			envelope[name] = data
+			return envelope
+		}
+	}
+	return data
+}
+
+// mockItemSchema returns the schema for items of the operation's
+// resource, preferring the output of the resource getter
+func mockItemSchema(op Operation) Schema {
+	s := op.Output()
+	if getter := ResourceGetter(op.Resource()); getter != nil && getter.Output() != nil {
+		s = getter.Output()
+	}
+	if s != nil && s.Type() == "array" {
+		return s.Items()
+	}
+	return s
+}
+
+// mockMaxDepth limits synthesized values of recursive schemas
+const mockMaxDepth = 6
+
+// mockValue synthesizes a value for a schema from its example,
+// default or enum, falling back to a placeholder for its type
+func mockValue(s Schema, depth int) any {
+	if s == nil || depth > mockMaxDepth {
+		return nil
+	}
+	for _, v := range []string{s.Example(), s.Default()} {
+		if v == "" {
+			continue
+		}
+		if value, ok := parseSchemaValue(s, v); ok {
+			return value
+		}
+	}
+	if enum := s.Enum(); len(enum) > 0 {
+		if value, ok := parseEnumValue(s, enum[0]); ok {
+			return value
+		}
+	}
+	if variants := append(s.OneOf(), s.AnyOf()...); len(variants) > 0 {
+		return mockValue(variants[0], depth+1)
+	}
+	switch effectiveType(s) {
+	case "object":
+		props := s.Properties()
+		obj := map[string]any{}
+		for _, prop := range props {
+			if prop.WriteOnly() {
+				continue
+			}
+			obj[prop.Name()] = mockValue(prop, depth+1)
+		}
+		return obj
+	case "array":
+		if item := mockValue(s.Items(), depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "integer", "number":
+		if min := s.Minimum(); min != nil {
+			return *min
+		}
+		return float64(0)
+	case "boolean":
+		return false
+	case "string":
+		switch s.Format() {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	}
+	return nil
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v any) {
+	if v == nil || status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,168 @@
+package integra
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testOpenAPIMock = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+servers:
+  - url: https://api.example.com/v2
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  pets:
+                    type: array
+                    items:
+                      $ref: "#/components/schemas/Pet"
+                  total:
+                    type: integer
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        "201":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+  /pets/{pet_id}:
+    parameters:
+      - name: pet_id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+    delete:
+      responses:
+        "204":
+          description: deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+          example: Rex
+        status:
+          type: string
+          enum: [available, sold]
+`
+
+func TestMock(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	server := httptest.NewServer(NewMock(s))
+	defer server.Close()
+	s.SetBaseURL(server.URL)
+
+	call := func(opName string, in map[string]any) (int, map[string]any) {
+		t.Helper()
+		op := testOperation(t, s, "pet", opName)
+		req, err := MakeRequest(op, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, created := call("create", map[string]any{"name": "Fido"})
+	if status != 201 {
+		t.Fatalf("create status = %d; want 201", status)
+	}
+	if created["name"] != "Fido" || created["status"] != "available" || created["id"] != float64(1) {
+		t.Errorf("unexpected created pet: %v", created)
+	}
+
+	status, got := call("get", map[string]any{"pet_id": 1})
+	if status != 200 || got["name"] != "Fido" {
+		t.Errorf("get = %d %v", status, got)
+	}
+
+	_, listing := call("list", nil)
+	pets, _ := listing["pets"].([]any)
+	if len(pets) != 1 {
+		t.Errorf("expected 1 pet in listing, got %v", listing)
+	}
+
+	if status, _ := call("delete", map[string]any{"pet_id": 1}); status != 204 {
+		t.Errorf("delete status = %d; want 204", status)
+	}
+	if status, _ := call("get", map[string]any{"pet_id": 1}); status != 404 {
+		t.Errorf("get after delete status = %d; want 404", status)
+	}
+}
+
+const testOpenAPIExamples = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+paths: {}
+components:
+  schemas:
+    Settings:
+      type: object
+      example:
+        theme: dark
+        sizes: [1, 2]
+    Tags:
+      type: array
+      default: [a, b]
+    Limit:
+      type: integer
+      example: 10000000
+`
+
+func TestMockValueExamples(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIExamples)
+	schema := func(name string) Schema {
+		return &openapiSchema{name: name, schema: s.schema.Get("components", "schemas", name)}
+	}
+
+	if ex := schema("Settings").Example(); ex != `{"sizes":[1,2],"theme":"dark"}` {
+		t.Errorf("Example() = %s", ex)
+	}
+	settings, ok := mockValue(schema("Settings"), 0).(map[string]any)
+	if !ok || settings["theme"] != "dark" {
+		t.Errorf("mockValue(Settings) = %v", settings)
+	}
+	if tags, ok := mockValue(schema("Tags"), 0).([]any); !ok || len(tags) != 2 {
+		t.Errorf("mockValue(Tags) = %v", tags)
+	}
+	if limit := mockValue(schema("Limit"), 0); limit != float64(10000000) {
+		t.Errorf("mockValue(Limit) = %v", limit)
+	}
+}
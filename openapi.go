@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"fmt"
 	"log"
+	"maps"
 	"net/url"
 	"path"
 	"regexp"
@@ -17,9 +18,14 @@ import (
 )
 
 type openapiService struct {
-	name   string
-	meta   *Value
-	schema *Value
+	name    string
+	meta    *Value
+	schema  *Value
+	baseURL string
+
+	// server and serverVars are the selected server, see SelectServer
+	server     string
+	serverVars map[string]string
 
 	cachedRes []*openapiResource
 }
@@ -63,7 +69,13 @@ func (s *openapiService) Categories() []string {
 }
 
 func (s *openapiService) BaseURL() string {
-	baseURL := AsOrZero[string](s.schema.Get("servers", 0, "url"))
+	if s.baseURL != "" {
+		return s.baseURL
+	}
+	var baseURL string
+	if srv, ok := s.selectedServer(s.Servers()); ok {
+		baseURL, _ = srv.Expand(serverVars(srv, s.serverVars))
+	}
 	baseExtension := s.meta.Get("extendBaseTo")
 	if !baseExtension.IsNil() {
 		baseURL, _ = url.JoinPath(baseURL, MustAs[string](baseExtension))
@@ -71,6 +83,31 @@ func (s *openapiService) BaseURL() string {
 	return baseURL
 }
 
+func (s *openapiService) SetBaseURL(u string) {
+	s.baseURL = u
+}
+
+func (s *openapiService) selectServer(server string, vars map[string]string) {
+	s.server = server
+	s.serverVars = vars
+}
+
+// selectedServer returns the selected server of servers, or the
+// first if the selection isn't one of them
+func (s *openapiService) selectedServer(servers []Server) (Server, bool) {
+	if len(servers) == 0 {
+		return Server{}, false
+	}
+	if idx := findServer(servers, s.server); idx >= 0 {
+		return servers[idx], true
+	}
+	return servers[0], true
+}
+
+func (s *openapiService) Servers() []Server {
+	return openapiServers(s.schema.Get("servers"))
+}
+
 func (s *openapiService) DocsURL() string {
 	return AsOrZero[string](s.schema.Get("externalDocs", "url"))
 }
@@ -172,6 +209,91 @@ func (s *openapiService) Resources() []Resource {
 	return out
 }
 
+// specVersion returns the OpenAPI version of the description
+// reduced to major.minor, or "2.0" for Swagger documents
+func (s *openapiService) specVersion() string {
+	v := AsOrZero[string](s.schema.Get("openapi"))
+	if v == "" {
+		return AsOrZero[string](s.schema.Get("swagger"))
+	}
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return v
+	}
+	return strings.Join(parts[:2], ".")
+}
+
+func (s *openapiService) isOpenAPI31() bool {
+	return s.specVersion() == "3.1"
+}
+
+func (s *openapiService) Webhook(name string) (Webhook, error) {
+	for _, w := range s.Webhooks() {
+		if w.Name() == name {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("webhook '%s' not found", name)
+}
+
+func (s *openapiService) Webhooks() (webhooks []Webhook) {
+	// webhooks are only part of 3.1, but some
+	// 3.0 descriptions use an extension for them
+	key := "x-webhooks"
+	if s.isOpenAPI31() {
+		key = "webhooks"
+	}
+	webhooks = s.callbacks()
+	hooks := s.schema.Get(key)
+	if hooks.IsNil() {
+		return webhooks
+	}
+	for _, name := range hooks.Keys() {
+		for _, method := range hooks.Get(name).Keys() {
+			if strings.HasPrefix(method, "x-") || method == "parameters" {
+				continue
+			}
+			webhooks = append(webhooks, &openapiWebhook{
+				name:    name,
+				method:  method,
+				service: s,
+				schema:  hooks.Get(name, method),
+			})
+		}
+	}
+	return
+}
+
+// callbacks returns the callbacks of operations as webhooks,
+// since they are also requests sent by the service
+func (s *openapiService) callbacks() (webhooks []Webhook) {
+	paths := s.schema.Get("paths")
+	for _, pathKey := range paths.Keys() {
+		for _, method := range paths.Get(pathKey).Keys() {
+			callbacks := paths.Get(pathKey, method, "callbacks")
+			if callbacks.IsNil() {
+				continue
+			}
+			for _, name := range callbacks.Keys() {
+				for _, expr := range callbacks.Get(name).Keys() {
+					for _, cbMethod := range callbacks.Get(name, expr).Keys() {
+						if strings.HasPrefix(cbMethod, "x-") || cbMethod == "parameters" {
+							continue
+						}
+						webhooks = append(webhooks, &openapiWebhook{
+							name:    name,
+							method:  cbMethod,
+							service: s,
+							schema:  callbacks.Get(name, expr, cbMethod),
+						})
+					}
+				}
+			}
+		}
+	}
+	return webhooks
+}
+
 type openapiResource struct {
 	name    string
 	service *openapiService
@@ -207,7 +329,7 @@ func (r *openapiResource) Superset() Resource {
 		}
 		rr, err := r.service.Resource(supersetName)
 		if err != nil {
-			log.Printf("!! unable to link superset on '%s': %w\n", r.Name(), err)
+			log.Printf("!! unable to link superset on '%s': %v\n", r.Name(), err)
 		} else {
 			return rr
 		}
@@ -228,7 +350,7 @@ func (r *openapiResource) Parent() Resource {
 		}
 		rr, err := r.service.Resource(parentName)
 		if err != nil {
-			log.Printf("!! unable to reparent '%s': %w\n", r.Name(), err)
+			log.Printf("!! unable to reparent '%s': %v\n", r.Name(), err)
 		} else {
 			return rr
 		}
@@ -340,7 +462,7 @@ func (r *openapiResource) primaryPath() *openapiPath {
 
 func (r *openapiResource) Description() string {
 	path := r.primaryPath()
-	schema := path.schema.Get("get", "responses", "200", "content", "application/json", "schema")
+	_, _, schema := primaryResponse(path.schema.Get("get", "responses"))
 	if schema.IsNil() {
 		return ""
 	}
@@ -626,7 +748,35 @@ func (o *openapiOperation) Description() string {
 }
 
 func (o *openapiOperation) URL() string {
-	return o.path.resource.expandToURL(o.path.name())
+	service := o.path.resource.service
+	opServers := o.operationServers()
+	if service.baseURL != "" || len(opServers) == 0 {
+		return o.path.resource.expandToURL(o.path.name())
+	}
+	// operations can be served from elsewhere than the service
+	srv, _ := service.selectedServer(opServers)
+	baseURL, _ := srv.Expand(serverVars(srv, service.serverVars))
+	baseExtension := AsOrZero[string](service.meta.Get("extendBaseTo"))
+	u, _ := url.JoinPath(baseURL, baseExtension, o.path.name())
+	u = strings.ReplaceAll(u, "%7B", "{")
+	u = strings.ReplaceAll(u, "%7D", "}")
+	return u
+}
+
+func (o *openapiOperation) Servers() []Server {
+	if servers := o.operationServers(); len(servers) > 0 {
+		return servers
+	}
+	return o.path.resource.service.Servers()
+}
+
+// operationServers returns servers declared for
+// the operation or its path, if any
+func (o *openapiOperation) operationServers() []Server {
+	if servers := openapiServers(o.schema.Get("servers")); len(servers) > 0 {
+		return servers
+	}
+	return openapiServers(o.path.schema.Get("servers"))
 }
 
 func (o *openapiOperation) Method() string {
@@ -690,16 +840,33 @@ func (o *openapiOperation) Parameters() (params []Schema) {
 }
 
 func (o *openapiOperation) Input() Schema {
-	reqRaw := o.schema.Get("requestBody", "content", "application/json", "schema")
-	if reqRaw.IsNil() {
+	inputs := o.Inputs()
+	if len(inputs) == 0 {
 		return nil
 	}
-	return &openapiSchema{
-		name:      "(input)",
-		op:        o,
-		writeOnly: true,
-		schema:    reqRaw,
+	return inputs[PreferredMediaType(slices.Sorted(maps.Keys(inputs)))]
+}
+
+func (o *openapiOperation) Inputs() map[string]Schema {
+	content := o.schema.Get("requestBody", "content")
+	if content.IsNil() {
+		return nil
 	}
+	inputs := make(map[string]Schema)
+	for _, mediaType := range content.Keys() {
+		reqRaw := content.Get(mediaType, "schema")
+		if reqRaw.IsNil() {
+			// raw bodies often have no schema
+			reqRaw = New(map[string]any{})
+		}
+		inputs[mediaType] = &openapiSchema{
+			name:      "(input)",
+			op:        o,
+			writeOnly: true,
+			schema:    reqRaw,
+		}
+	}
+	return inputs
 }
 
 func (o *openapiOperation) Response() Schema {
@@ -711,7 +878,7 @@ func (o *openapiOperation) Response() Schema {
 }
 
 func (o *openapiOperation) responseSchema() *openapiSchema {
-	s := o.schema.Get("responses", "200", "content", "application/json", "schema")
+	_, _, s := primaryResponse(o.schema.Get("responses"))
 	if s.IsNil() {
 		return nil
 	}
@@ -722,6 +889,35 @@ func (o *openapiOperation) responseSchema() *openapiSchema {
 	}
 }
 
+func (o *openapiOperation) Responses() map[string]map[string]Schema {
+	responses := o.schema.Get("responses")
+	if responses.IsNil() {
+		return nil
+	}
+	out := make(map[string]map[string]Schema)
+	for _, status := range responses.Keys() {
+		if strings.HasPrefix(status, "x-") {
+			continue
+		}
+		content := responses.Get(status, "content")
+		out[status] = make(map[string]Schema)
+		for _, mediaType := range content.Keys() {
+			s := content.Get(mediaType, "schema")
+			if s.IsNil() {
+				// content without a schema is still
+				// a declared media type for the status
+				s = New(map[string]any{})
+			}
+			out[status][mediaType] = &openapiSchema{
+				name:   "(response)",
+				op:     o,
+				schema: s,
+			}
+		}
+	}
+	return out
+}
+
 func (o *openapiOperation) unwrappedItem() (*openapiSchema, bool) {
 	wrapsRaw := o.Resource().Service().Meta().Get("wrapsItems")
 	if wrapsRaw.IsNil() {
@@ -738,7 +934,7 @@ func (o *openapiOperation) unwrappedItem() (*openapiSchema, bool) {
 
 	// look for prop by name of resource
 	for _, name := range NameVariants(o.path.resource.name) {
-		if s := resp.schema.Get("properties", name); !s.IsNil() && AsOrZero[string](s.Get("type")) == "object" {
+		if s := resp.schema.Get("properties", name); !s.IsNil() && schemaType(s) == "object" {
 			// response has item under resource name or variant
 			return &openapiSchema{
 				name:   name,
@@ -749,7 +945,7 @@ func (o *openapiOperation) unwrappedItem() (*openapiSchema, bool) {
 	}
 
 	// look for "item" prop
-	if s := resp.schema.Get("properties", "item"); !s.IsNil() && AsOrZero[string](s.Get("type")) == "object" {
+	if s := resp.schema.Get("properties", "item"); !s.IsNil() && schemaType(s) == "object" {
 		// response has item under "item" key
 		return &openapiSchema{
 			name:   "item",
@@ -773,7 +969,7 @@ func (o *openapiOperation) listingResponse() (*openapiSchema, bool) {
 		return resp, true
 	}
 	for _, name := range NameVariants(o.path.resource.name) {
-		if s := resp.schema.Get("properties", name); !s.IsNil() && AsOrZero[string](s.Get("type")) == "array" {
+		if s := resp.schema.Get("properties", name); !s.IsNil() && schemaType(s) == "array" {
 			// response has array under resource name or variant
 			return &openapiSchema{
 				name:   name,
@@ -782,7 +978,7 @@ func (o *openapiOperation) listingResponse() (*openapiSchema, bool) {
 			}, true
 		}
 	}
-	if s := resp.schema.Get("properties", "items"); !s.IsNil() && AsOrZero[string](s.Get("type")) == "array" {
+	if s := resp.schema.Get("properties", "items"); !s.IsNil() && schemaType(s) == "array" {
 		// response has array under "items" key
 		return &openapiSchema{
 			name:   "items",
@@ -811,6 +1007,48 @@ func (o *openapiOperation) Output() Schema {
 	return resp
 }
 
+type openapiWebhook struct {
+	name    string
+	method  string
+	service *openapiService
+	schema  *Value
+}
+
+func (w *openapiWebhook) Service() Service {
+	return w.service
+}
+
+func (w *openapiWebhook) Name() string {
+	return w.name
+}
+
+func (w *openapiWebhook) ID() string {
+	return AsOrZero[string](w.schema.Get("operationId"))
+}
+
+func (w *openapiWebhook) Description() string {
+	summary := AsOrZero[string](w.schema.Get("summary"))
+	if summary != "" {
+		return strings.TrimSpace(summary)
+	}
+	return strings.TrimSpace(AsOrZero[string](w.schema.Get("description")))
+}
+
+func (w *openapiWebhook) Method() string {
+	return w.method
+}
+
+func (w *openapiWebhook) Input() Schema {
+	reqRaw := w.schema.Get("requestBody", "content", "application/json", "schema")
+	if reqRaw.IsNil() {
+		return nil
+	}
+	return &openapiSchema{
+		name:   "(input)",
+		schema: reqRaw,
+	}
+}
+
 type openapiParameter struct {
 	schema *Value
 
@@ -830,11 +1068,25 @@ func (p *openapiParameter) Description() string {
 }
 
 func (p *openapiParameter) Type() string {
-	return AsOrZero[string](p.schema.Get("schema", "type"))
+	return schemaType(p.schema.Get("schema"))
+}
+
+// valueSchema returns the schema of the parameter value, for the
+// keywords a parameter takes from its schema
+func (p *openapiParameter) valueSchema() *openapiSchema {
+	return &openapiSchema{name: p.Name(), schema: p.schema.Get("schema")}
+}
+
+func (p *openapiParameter) Items() Schema {
+	return p.valueSchema().Items()
 }
 
 func (p *openapiParameter) Enum() []string {
-	return AsOrZero[[]string](p.schema.Get("schema", "enum"))
+	return p.valueSchema().Enum()
+}
+
+func (p *openapiParameter) Const() string {
+	return p.valueSchema().Const()
 }
 
 func (p *openapiParameter) EnumDesc() []string {
@@ -854,15 +1106,76 @@ func (p *openapiParameter) Format() string {
 }
 
 func (p *openapiParameter) Default() string {
-	return AsOrZero[string](p.schema.Get("schema", "default"))
+	return schemaText(p.schema.Get("schema", "default"))
 }
 
 func (p *openapiParameter) Nullable() bool {
-	return AsOrZero[bool](p.schema.Get("schema", "nullable"))
+	return schemaNullable(p.schema.Get("schema"))
 }
 
 func (p *openapiParameter) Example() string {
-	return AsOrZero[string](p.schema.Get("example"))
+	example := schemaText(p.schema.Get("example"))
+	if example != "" {
+		return example
+	}
+	// examples on parameters are a map of named example objects
+	examples := p.schema.Get("examples")
+	for _, name := range examples.Keys() {
+		if v := schemaText(examples.Get(name, "value")); v != "" {
+			return v
+		}
+	}
+	return p.valueSchema().Example()
+}
+
+func (p *openapiParameter) Deprecated() bool {
+	return AsOrZero[bool](p.schema.Get("deprecated"))
+}
+
+func (p *openapiParameter) Pattern() string {
+	return AsOrZero[string](p.schema.Get("schema", "pattern"))
+}
+
+func (p *openapiParameter) Minimum() *float64 {
+	min := p.schema.Get("schema", "minimum")
+	if min.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](min)
+	return &v
+}
+
+func (p *openapiParameter) Maximum() *float64 {
+	max := p.schema.Get("schema", "maximum")
+	if max.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](max)
+	return &v
+}
+
+func (p *openapiParameter) MultipleOf() *float64 {
+	return p.valueSchema().MultipleOf()
+}
+
+func (p *openapiParameter) MinLength() *int {
+	return p.valueSchema().MinLength()
+}
+
+func (p *openapiParameter) MaxLength() *int {
+	return p.valueSchema().MaxLength()
+}
+
+func (p *openapiParameter) MinItems() *int {
+	return p.valueSchema().MinItems()
+}
+
+func (p *openapiParameter) MaxItems() *int {
+	return p.valueSchema().MaxItems()
+}
+
+func (p *openapiParameter) UniqueItems() bool {
+	return p.valueSchema().UniqueItems()
 }
 
 type openapiSchema struct {
@@ -888,11 +1201,16 @@ func (s *openapiSchema) Description() string {
 }
 
 func (s *openapiSchema) Type() string {
-	return AsOrZero[string](s.schema.Get("type"))
+	return schemaType(s.schema)
 }
 
 func (s *openapiSchema) Enum() []string {
-	return AsOrZero[[]string](s.schema.Get("enum"))
+	enum := s.schema.Get("enum")
+	if enum.IsNil() && !s.schema.Get("const").IsNil() {
+		// const is a single value enum
+		return []string{s.Const()}
+	}
+	return schemaTexts(enum)
 }
 
 func (s *openapiSchema) EnumDesc() []string {
@@ -908,23 +1226,61 @@ func (s *openapiSchema) Format() string {
 }
 
 func (s *openapiSchema) Default() string {
-	return AsOrZero[string](s.schema.Get("default"))
+	return schemaText(s.schema.Get("default"))
 }
 
 func (s *openapiSchema) Nullable() bool {
-	return AsOrZero[bool](s.schema.Get("nullable"))
+	return schemaNullable(s.schema)
 }
 
 func (s *openapiSchema) Example() string {
-	return AsOrZero[string](s.schema.Get("example"))
+	// 3.1 schemas use an examples array instead of example
+	return cmp.Or(
+		schemaText(s.schema.Get("example")),
+		schemaText(s.schema.Get("examples", 0)),
+	)
 }
 
-func (s *openapiSchema) Minimum() *int {
+func (s *openapiSchema) WriteOnly() bool {
+	return AsOrZero[bool](s.schema.Get("writeOnly"))
+}
+
+func (s *openapiSchema) Deprecated() bool {
+	return AsOrZero[bool](s.schema.Get("deprecated"))
+}
+
+func (s *openapiSchema) Pattern() string {
+	return AsOrZero[string](s.schema.Get("pattern"))
+}
+
+func (s *openapiSchema) Const() string {
+	return schemaText(s.schema.Get("const"))
+}
+
+func (s *openapiSchema) Minimum() *float64 {
 	min := s.schema.Get("minimum")
 	if min.IsNil() {
 		return nil
 	}
-	v := AsOrZero[int](min)
+	v := AsOrZero[float64](min)
+	return &v
+}
+
+func (s *openapiSchema) Maximum() *float64 {
+	max := s.schema.Get("maximum")
+	if max.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](max)
+	return &v
+}
+
+func (s *openapiSchema) MultipleOf() *float64 {
+	mult := s.schema.Get("multipleOf")
+	if mult.IsNil() {
+		return nil
+	}
+	v := AsOrZero[float64](mult)
 	return &v
 }
 
@@ -946,6 +1302,28 @@ func (s *openapiSchema) MaxLength() *int {
 	return &v
 }
 
+func (s *openapiSchema) MinItems() *int {
+	min := s.schema.Get("minItems")
+	if min.IsNil() {
+		return nil
+	}
+	v := AsOrZero[int](min)
+	return &v
+}
+
+func (s *openapiSchema) MaxItems() *int {
+	max := s.schema.Get("maxItems")
+	if max.IsNil() {
+		return nil
+	}
+	v := AsOrZero[int](max)
+	return &v
+}
+
+func (s *openapiSchema) UniqueItems() bool {
+	return AsOrZero[bool](s.schema.Get("uniqueItems"))
+}
+
 func (s *openapiSchema) Required() bool {
 	if s.requiredProp {
 		return true
@@ -1000,14 +1378,14 @@ func (s *openapiSchema) Items() Schema {
 	}
 }
 
-func (s *openapiSchema) AnyOf() (schemas []Schema) {
-	anyOf := s.schema.Get("anyOf")
-	if anyOf.IsNil() {
+func (s *openapiSchema) PrefixItems() (schemas []Schema) {
+	prefixItems := s.schema.Get("prefixItems")
+	if prefixItems.IsNil() {
 		return
 	}
-	for idx, schemaRaw := range anyOf.Items() {
+	for idx, schemaRaw := range prefixItems.Items() {
 		schemas = append(schemas, &openapiSchema{
-			name:      fmt.Sprintf("%s/%d", s.name, idx),
+			name:      fmt.Sprintf("(item)/%d", idx),
 			op:        s.op,
 			writeOnly: s.writeOnly,
 			schema:    schemaRaw,
@@ -1016,14 +1394,63 @@ func (s *openapiSchema) AnyOf() (schemas []Schema) {
 	return
 }
 
-func (s *openapiSchema) OneOf() (schemas []Schema) {
-	oneOf := s.schema.Get("oneOf")
-	if oneOf.IsNil() {
-		return
+func (s *openapiSchema) AdditionalProperties() Schema {
+	additional := s.schema.Get("additionalProperties")
+	if additional.IsNil() {
+		return nil
 	}
-	for idx, schemaRaw := range oneOf.Items() {
+	if allowed, isBool := additional.Data().(bool); isBool {
+		if !allowed {
+			return nil
+		}
+		// true allows values of any type
+		additional = New(map[string]any{})
+	}
+	return &openapiSchema{
+		name:      "(value)",
+		op:        s.op,
+		writeOnly: s.writeOnly,
+		schema:    additional,
+	}
+}
+
+func (s *openapiSchema) AllOf() []Schema {
+	return s.subschemas("allOf")
+}
+
+func (s *openapiSchema) AnyOf() []Schema {
+	return s.subschemas("anyOf")
+}
+
+func (s *openapiSchema) OneOf() []Schema {
+	return s.subschemas("oneOf")
+}
+
+// subschemas returns the schemas listed under a composition keyword.
+// Variants are named after the schema they reference when possible.
+func (s *openapiSchema) subschemas(keyword string) (schemas []Schema) {
+	var (
+		items []*Value
+		raw   []any
+	)
+	if keyword == "allOf" {
+		// allOf is merged down, leaving the unresolved members to AllOf
+		items = s.schema.AllOf()
+		for _, item := range items {
+			raw = append(raw, item.Data())
+		}
+	} else {
+		list := s.schema.Get(keyword)
+		raw, _ = list.Data().([]any)
+		items = list.Items()
+	}
+	for idx, schemaRaw := range items {
+		name := fmt.Sprintf("%s/%d", s.name, idx)
+		if idx < len(raw) {
+			name = cmp.Or(refName(raw[idx]), name)
+		}
 		schemas = append(schemas, &openapiSchema{
-			name:      fmt.Sprintf("%s/%d", s.name, idx),
+			name:      name,
 			op:        s.op,
 			writeOnly: s.writeOnly,
 			schema:    schemaRaw,
@@ -1031,3 +1458,133 @@ func (s *openapiSchema) OneOf() (schemas []Schema) {
 	}
 	return
 }
+
+func (s *openapiSchema) Discriminator() *Discriminator {
+	d := s.schema.Get("discriminator")
+	if d.IsNil() {
+		return nil
+	}
+	discriminator := &Discriminator{
+		PropertyName: AsOrZero[string](d.Get("propertyName")),
+		Mapping:      make(map[string]string),
+	}
+	mapping := d.Get("mapping")
+	for _, value := range mapping.Keys() {
+		// mapping targets can be references or schema names
+		target := AsOrZero[string](mapping.Get(value))
+		discriminator.Mapping[value] = path.Base(target)
+	}
+	return discriminator
+}
+
+// schemaType returns the primary type of a schema. OpenAPI 3.1 allows a list
+// of types (usually to include "null") and nullable values are often written
+// as a union with a null schema, so these are reduced to the non-null type.
+func schemaType(schema *Value) string {
+	t := schema.Get("type")
+	if types, err := As[[]string](t); err == nil {
+		for _, typ := range types {
+			if typ != "null" {
+				return typ
+			}
+		}
+		if len(types) > 0 {
+			return types[0]
+		}
+		return ""
+	}
+	if typ := AsOrZero[string](t); typ != "" {
+		return typ
+	}
+	if variant := nullableVariant(schema); variant != nil {
+		return schemaType(variant)
+	}
+	return ""
+}
+
+// schemaNullable checks for the 3.0 nullable keyword as well as
+// the 3.1 forms of a "null" type or a union with a null schema
+func schemaNullable(schema *Value) bool {
+	if AsOrZero[bool](schema.Get("nullable")) {
+		return true
+	}
+	t := schema.Get("type")
+	if types, err := As[[]string](t); err == nil {
+		return slices.Contains(types, "null")
+	}
+	if AsOrZero[string](t) == "null" {
+		return true
+	}
+	return nullableVariant(schema) != nil
+}
+
+// nullableVariant returns the non-null schema of an anyOf or oneOf
+// union of exactly one schema and a null schema
+func nullableVariant(schema *Value) *Value {
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		variants := schema.Get(keyword).Items()
+		if len(variants) != 2 {
+			continue
+		}
+		for idx, variant := range variants {
+			if AsOrZero[string](variant.Get("type")) == "null" {
+				return variants[1-idx]
+			}
+		}
+	}
+	return nil
+}
+
+// refName returns the name of the schema referenced
+// by unresolved schema data, if it is a reference
+func refName(raw any) string {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return ""
+	}
+	ref, ok := m["$ref"].(string)
+	if !ok {
+		return ""
+	}
+	return path.Base(ref)
+}
+
+// primaryResponse finds the principal success response in an OpenAPI
+// responses object, which is the lowest 2xx status with content,
+// preferring JSON media types. A nil schema is returned if there
+// is no successful response with content.
+func primaryResponse(responses *Value) (status string, mediaType string, schema *Value) {
+	for _, code := range responses.Keys() {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		content := responses.Get(code, "content")
+		types := content.Keys()
+		if len(types) == 0 {
+			continue
+		}
+		mediaType = PreferredMediaType(types)
+		return code, mediaType, content.Get(mediaType, "schema")
+	}
+	return "", "", New(nil)
+}
+
+func openapiServers(serversRaw *Value) (servers []Server) {
+	for _, serverRaw := range serversRaw.Items() {
+		server := Server{
+			URL:         AsOrZero[string](serverRaw.Get("url")),
+			Description: AsOrZero[string](serverRaw.Get("description")),
+			Variables:   make(map[string]ServerVariable),
+		}
+		vars := serverRaw.Get("variables")
+		for _, name := range vars.Keys() {
+			server.Variables[name] = ServerVariable{
+				Default:     AsOrZero[string](vars.Get(name, "default")),
+				Enum:        AsOrZero[[]string](vars.Get(name, "enum")),
+				Description: AsOrZero[string](vars.Get(name, "description")),
+			}
+		}
+		servers = append(servers, server)
+	}
+	return
+}
@@ -0,0 +1,393 @@
+package integra
+
+import (
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"tractor.dev/integra/internal/jsonaccess"
+)
+
+func loadTestOpenAPI(t *testing.T, doc string) *openapiService {
+	t.Helper()
+	var raw map[any]any
+	if err := yaml.Unmarshal([]byte(doc), &raw); err != nil {
+		t.Fatal(err)
+	}
+	root := jsonaccess.New(convertYAMLToStringMap(raw))
+	resolver := jsonaccess.NewPointerResolver(root)
+	root = root.WithResolver(resolver).WithAllOfMerge()
+	return &openapiService{
+		name:   "test",
+		schema: root,
+		meta:   jsonaccess.New(map[string]any{"latest": "1"}),
+	}
+}
+
+const testOpenAPI31 = `
+openapi: 3.1.0
+info:
+  title: Test
+  version: "1.0"
+servers:
+  - url: https://api.example.com
+paths:
+  /pets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: [string, "null"]
+          examples:
+            first:
+              value: abc
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: [string, "null"]
+          examples: [Rex]
+        kind:
+          const: dog
+        owner:
+          anyOf:
+            - $ref: "#/$defs/Owner"
+            - type: "null"
+        position:
+          type: array
+          prefixItems:
+            - type: number
+            - type: number
+$defs:
+  Owner:
+    type: object
+webhooks:
+  newPet:
+    post:
+      summary: A pet was added
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+`
+
+func TestOpenAPI31Schemas(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPI31)
+	if v := s.specVersion(); v != "3.1" {
+		t.Fatalf("specVersion() = %q; want 3.1", v)
+	}
+
+	r, err := s.Resource("pet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := r.Operation("get")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	param := op.Parameters()[0]
+	if param.Type() != "string" || !param.Nullable() || param.Example() != "abc" {
+		t.Errorf("param = (%q, %v, %q); want (string, true, abc)", param.Type(), param.Nullable(), param.Example())
+	}
+
+	out := op.Output()
+	tests := []struct {
+		prop     string
+		typ      string
+		nullable bool
+	}{
+		{"name", "string", true},
+		{"owner", "object", true},
+		{"position", "array", false},
+	}
+	for _, test := range tests {
+		prop, err := out.Property(test.prop)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prop.Type() != test.typ || prop.Nullable() != test.nullable {
+			t.Errorf("%s = (%q, %v); want (%q, %v)", test.prop, prop.Type(), prop.Nullable(), test.typ, test.nullable)
+		}
+	}
+
+	name, _ := out.Property("name")
+	if name.Example() != "Rex" {
+		t.Errorf("name.Example() = %q; want Rex", name.Example())
+	}
+	kind, _ := out.Property("kind")
+	if kind.Const() != "dog" || len(kind.Enum()) != 1 {
+		t.Errorf("kind = (%q, %v); want (dog, [dog])", kind.Const(), kind.Enum())
+	}
+	position, _ := out.Property("position")
+	if n := len(position.PrefixItems()); n != 2 {
+		t.Errorf("len(position.PrefixItems()) = %d; want 2", n)
+	}
+}
+
+func TestOpenAPI31Webhooks(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPI31)
+	wh, err := s.Webhook("newPet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wh.Method() != "post" || wh.Description() != "A pet was added" {
+		t.Errorf("webhook = (%q, %q)", wh.Method(), wh.Description())
+	}
+	if _, err := wh.Input().Property("owner"); err != nil {
+		t.Error(err)
+	}
+}
+
+const testOpenAPIDiscriminator = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+paths: {}
+components:
+  schemas:
+    Pet:
+      oneOf:
+        - $ref: "#/components/schemas/Dog"
+        - $ref: "#/components/schemas/Cat"
+      discriminator:
+        propertyName: petType
+        mapping:
+          doggo: "#/components/schemas/Dog"
+    Dog:
+      type: object
+      required: [bark]
+      properties:
+        petType:
+          type: string
+        bark:
+          type: boolean
+    Cat:
+      type: object
+      required: [meow]
+      properties:
+        petType:
+          type: string
+        meow:
+          type: boolean
+`
+
+func TestSelectVariant(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIDiscriminator)
+	pet := &openapiSchema{name: "pet", schema: s.schema.Get("components", "schemas", "Pet")}
+
+	d := pet.Discriminator()
+	if d == nil || d.PropertyName != "petType" || d.Mapping["doggo"] != "Dog" {
+		t.Fatalf("Discriminator() = %#v", d)
+	}
+
+	tests := []struct {
+		data     map[string]any
+		expected string
+	}{
+		{map[string]any{"petType": "doggo", "bark": true}, "Dog"},
+		{map[string]any{"petType": "Cat", "meow": true}, "Cat"},
+		{map[string]any{"petType": "unknown", "meow": true}, "Cat"},
+		{map[string]any{"bark": true}, ""},
+	}
+	for _, test := range tests {
+		var name string
+		if v := SelectVariant(pet, jsonaccess.New(test.data)); v != nil {
+			name = v.Name()
+		}
+		if name != test.expected {
+			t.Errorf("SelectVariant(%v) = %q; want %q", test.data, name, test.expected)
+		}
+	}
+}
+
+const testOpenAPIResponses = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+paths:
+  /invoices:
+    post:
+      responses:
+        "201":
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: string
+        "400":
+          content:
+            application/problem+json:
+              schema:
+                type: object
+  /invoices/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/pdf:
+              schema:
+                type: string
+                format: binary
+            text/csv: {}
+    delete:
+      responses:
+        "204":
+          description: deleted
+`
+
+func TestOperationResponses(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIResponses)
+	r, err := s.Resource("invoice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create, _ := r.Operation("create")
+	if out := create.Output(); out == nil || out.Type() != "object" {
+		t.Errorf("create.Output() = %v; want object from 201 response", out)
+	}
+	if _, ok := create.Responses()["400"]["application/problem+json"]; !ok {
+		t.Errorf("create.Responses() missing 400 error response: %v", create.Responses())
+	}
+
+	get, _ := r.Operation("get")
+	if out := get.Output(); out == nil || out.Format() != "binary" {
+		t.Errorf("get.Output() = %v; want binary string", out)
+	}
+	if n := len(get.Responses()["200"]); n != 2 {
+		t.Errorf("len(get.Responses()[200]) = %d; want 2", n)
+	}
+
+	del, _ := r.Operation("delete")
+	if del.Output() != nil {
+		t.Errorf("delete.Output() = %v; want nil", del.Output())
+	}
+	if _, ok := del.Responses()["204"]; !ok {
+		t.Errorf("delete.Responses() missing 204: %v", del.Responses())
+	}
+}
+
+const testOpenAPIAllOf = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+paths: {}
+components:
+  schemas:
+    Base:
+      properties:
+        id:
+          type: integer
+    Named:
+      allOf:
+        - $ref: "#/components/schemas/Base"
+        - properties:
+            name:
+              type: string
+`
+
+func TestSchemaAllOf(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIAllOf)
+	named := &openapiSchema{name: "named", schema: s.schema.Get("components", "schemas", "Named")}
+
+	var props []string
+	for _, prop := range named.Properties() {
+		props = append(props, prop.Name())
+	}
+	if len(props) != 2 {
+		t.Errorf("expected merged properties, got %v", props)
+	}
+	if slices.Contains(named.schema.Keys(), "allOf") {
+		t.Errorf("expected allOf to be merged away, got keys %v", named.schema.Keys())
+	}
+	members := named.AllOf()
+	if len(members) != 2 || members[0].Name() != "Base" || len(members[1].Properties()) != 1 {
+		t.Errorf("unexpected allOf members: %v", members)
+	}
+}
+
+const testOpenAPIParameters = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: name
+          in: query
+          schema:
+            type: string
+            minLength: 2
+            maxLength: 20
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            multipleOf: 10
+            default: 20
+          example: 50
+        - name: ids
+          in: query
+          schema:
+            type: array
+            minItems: 1
+            maxItems: 5
+            uniqueItems: true
+            items:
+              type: integer
+            default: [1, 2]
+        - name: kind
+          in: query
+          schema:
+            const: dog
+      responses:
+        "200":
+          description: pets
+`
+
+func TestOpenAPIParameterKeywords(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIParameters)
+	params := map[string]Schema{}
+	for _, p := range testOperation(t, s, "pet", "list").Parameters() {
+		params[p.Name()] = p
+	}
+
+	name := params["name"]
+	if name.MinLength() == nil || *name.MinLength() != 2 || name.MaxLength() == nil || *name.MaxLength() != 20 {
+		t.Errorf("name length = (%v, %v); want (2, 20)", name.MinLength(), name.MaxLength())
+	}
+	limit := params["limit"]
+	if limit.MultipleOf() == nil || *limit.MultipleOf() != 10 || limit.Default() != "20" || limit.Example() != "50" {
+		t.Errorf("limit = (%v, %q, %q); want (10, 20, 50)", limit.MultipleOf(), limit.Default(), limit.Example())
+	}
+	ids := params["ids"]
+	if ids.MinItems() == nil || *ids.MinItems() != 1 || ids.MaxItems() == nil || *ids.MaxItems() != 5 || !ids.UniqueItems() {
+		t.Errorf("ids items = (%v, %v, %v); want (1, 5, true)", ids.MinItems(), ids.MaxItems(), ids.UniqueItems())
+	}
+	if ids.Default() != "[1,2]" || ids.Items() == nil || ids.Items().Type() != "integer" {
+		t.Errorf("ids = (%q, %v); want ([1,2], integer items)", ids.Default(), ids.Items())
+	}
+	kind := params["kind"]
+	if kind.Const() != "dog" || len(kind.Enum()) != 1 {
+		t.Errorf("kind = (%q, %v); want (dog, [dog])", kind.Const(), kind.Enum())
+	}
+}
@@ -0,0 +1,172 @@
+package integra
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Policy decides which operations may be performed. Rules are checked in
+// order and the first matching rule decides, otherwise the default effect
+// applies. Policies are stored as YAML:
+//
+//	readOnly: false
+//	default: allow
+//	rules:
+//	  - effect: deny
+//	    selector: github.*.delete
+//	  - effect: deny
+//	    kinds: [purge]
+//	  - effect: allow
+//	    selector: github.issue.*
+//	    methods: [POST, PATCH]
+type Policy struct {
+	// ReadOnly only permits GET and HEAD operations, before any rules
+	ReadOnly bool `yaml:"readOnly"`
+
+	// Default is the effect when no rule matches, allow if empty
+	Default string `yaml:"default"`
+
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule matches operations and allows or denies them. Empty
+// fields match any operation.
+type PolicyRule struct {
+	// Effect is allow or deny
+	Effect string `yaml:"effect"`
+
+	// Selector is a glob of operation selectors, like github.*.delete
+	Selector string `yaml:"selector"`
+
+	// Methods are HTTP methods, like GET or DELETE
+	Methods []string `yaml:"methods"`
+
+	// Orientation is relative or absolute
+	Orientation string `yaml:"orientation"`
+
+	// Kinds are operation names without orientation, like list or purge
+	Kinds []string `yaml:"kinds"`
+}
+
+// PolicyError is returned for operations a policy doesn't permit
+type PolicyError struct {
+	Selector string
+	Reason   string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s is not permitted: %s", e.Selector, e.Reason)
+}
+
+// PolicyPath returns the path of the policy file, which can be
+// set with INTEGRA_POLICY or is in the user config directory
+func PolicyPath() string {
+	if p := os.Getenv("INTEGRA_POLICY"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "integra", "policy.yaml")
+}
+
+// LoadPolicy loads the policy file. If there is no policy file, an empty
+// policy that allows everything is returned. INTEGRA_READ_ONLY=1 turns
+// on read-only mode regardless of the file.
+func LoadPolicy() (*Policy, error) {
+	p := &Policy{}
+	b, err := os.ReadFile(PolicyPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(b, p); err != nil {
+			return nil, fmt.Errorf("%s: %w", PolicyPath(), err)
+		}
+	}
+	if p.Default != "" && p.Default != "allow" && p.Default != "deny" {
+		return nil, fmt.Errorf("%s: default must be allow or deny", PolicyPath())
+	}
+	for i, rule := range p.Rules {
+		if rule.Effect != "allow" && rule.Effect != "deny" {
+			return nil, fmt.Errorf("%s: rule %d: effect must be allow or deny", PolicyPath(), i+1)
+		}
+	}
+	if os.Getenv("INTEGRA_READ_ONLY") == "1" {
+		p.ReadOnly = true
+	}
+	return p, nil
+}
+
+// Check returns a PolicyError if the operation is not permitted
+func (p *Policy) Check(op Operation) error {
+	if p == nil {
+		return nil
+	}
+	selector := OperationSelector(op)
+	method := strings.ToUpper(op.Method())
+	if p.ReadOnly && method != http.MethodGet && method != http.MethodHead {
+		return &PolicyError{Selector: selector, Reason: "read-only mode only permits GET and HEAD"}
+	}
+	for i, rule := range p.Rules {
+		if !rule.matches(op) {
+			continue
+		}
+		if rule.Effect == "deny" {
+			return &PolicyError{Selector: selector, Reason: fmt.Sprintf("denied by policy rule %d", i+1)}
+		}
+		return nil
+	}
+	if p.Default == "deny" {
+		return &PolicyError{Selector: selector, Reason: "no policy rule allows it"}
+	}
+	return nil
+}
+
+func (r PolicyRule) matches(op Operation) bool {
+	if r.Selector != "" && !MatchSelector(r.Selector, OperationSelector(op)) {
+		return false
+	}
+	if len(r.Methods) > 0 && !slices.ContainsFunc(r.Methods, func(m string) bool {
+		return strings.EqualFold(m, op.Method())
+	}) {
+		return false
+	}
+	if r.Orientation != "" && r.Orientation != op.Orientation() {
+		return false
+	}
+	if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, op.AbsName()) {
+		return false
+	}
+	return true
+}
+
+// MatchSelector reports whether a selector matches a glob
+// pattern, as matched by Selector.Match
+func MatchSelector(pattern, selector string) bool {
+	p, err := ParseSelector(pattern)
+	if err != nil {
+		return false
+	}
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return false
+	}
+	return p.Match(sel)
+}
+
+// IsDestructive reports whether an operation deletes something
+func IsDestructive(op Operation) bool {
+	switch op.AbsName() {
+	case "delete", "purge":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package integra
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	list := testOperation(t, s, "pet", "list")
+	create := testOperation(t, s, "pet", "create")
+	del := testOperation(t, s, "pet", "delete")
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		allowed map[Operation]bool
+	}{
+		{"empty", &Policy{}, map[Operation]bool{list: true, create: true, del: true}},
+		{"read-only", &Policy{ReadOnly: true}, map[Operation]bool{list: true, create: false, del: false}},
+		{"selector", &Policy{Rules: []PolicyRule{
+			{Effect: "deny", Selector: "test.*.delete"},
+		}}, map[Operation]bool{list: true, create: true, del: false}},
+		{"kinds", &Policy{Rules: []PolicyRule{
+			{Effect: "deny", Kinds: []string{"delete", "purge"}},
+		}}, map[Operation]bool{list: true, create: true, del: false}},
+		{"default deny", &Policy{Default: "deny", Rules: []PolicyRule{
+			{Effect: "allow", Methods: []string{"get"}},
+			{Effect: "allow", Selector: "test.pet.create"},
+		}}, map[Operation]bool{list: true, create: true, del: false}},
+		{"first match", &Policy{Rules: []PolicyRule{
+			{Effect: "allow", Selector: "test.pet.create"},
+			{Effect: "deny", Methods: []string{"POST", "DELETE"}},
+		}}, map[Operation]bool{list: true, create: true, del: false}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for op, allowed := range test.allowed {
+				err := test.policy.Check(op)
+				var perr *PolicyError
+				if err != nil && !errors.As(err, &perr) {
+					t.Fatalf("unexpected error type: %v", err)
+				}
+				if (err == nil) != allowed {
+					t.Errorf("%s: allowed = %v; want %v (%v)", OperationSelector(op), err == nil, allowed, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	t.Setenv("INTEGRA_POLICY", path)
+	t.Setenv("INTEGRA_READ_ONLY", "")
+	tests := []struct {
+		policy string
+		ok     bool
+	}{
+		{"default: deny\nrules:\n  - effect: allow\n    selector: github.*.get\n", true},
+		{"default: allow\n", true},
+		{"default: Deny\n", false},
+		{"default: denied\n", false},
+		{"rules:\n  - effect: block\n", false},
+	}
+	for _, test := range tests {
+		if err := os.WriteFile(path, []byte(test.policy), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicy(); (err == nil) != test.ok {
+			t.Errorf("LoadPolicy(%q) error = %v; want ok %v", test.policy, err, test.ok)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package integra
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Profile bundles the credentials, base URL and default parameters
+// for using a service with a particular account. Profiles are stored
+// in a YAML file keyed by profile name then service name:
+//
+//	work:
+//	  github:
+//	    token: ghp_xxx
+//	    baseURL: https://github.example.com/api/v3
+//	    params:
+//	      owner: example-org
+//	  digitalocean:
+//	    tokenEnv: DO_WORK_TOKEN
+type Profile struct {
+	Name    string `yaml:"-"`
+	Service string `yaml:"-"`
+
+	// Token is used instead of the <SERVICE>_TOKEN environment variable.
+	// TokenEnv can name another environment variable to read it from.
+	Token    string `yaml:"token"`
+	TokenEnv string `yaml:"tokenEnv"`
+
+	BaseURL    string            `yaml:"baseURL"`
+	Server     string            `yaml:"server"`
+	ServerVars map[string]string `yaml:"serverVars"`
+
+	// Params are used for operation parameters not otherwise given
+	Params map[string]any `yaml:"params"`
+}
+
+// ProfilesPath returns the path of the profiles file, which can
+// be set with INTEGRA_PROFILES or is in the user config directory
+func ProfilesPath() string {
+	if p := os.Getenv("INTEGRA_PROFILES"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "integra", "profiles.yaml")
+}
+
+// LoadProfile loads the named profile for a service from the profiles
+// file. If name is empty, INTEGRA_PROFILE is used, and if that is empty
+// no profile is used and nil is returned. A profile from INTEGRA_PROFILE
+// without settings for the service also returns nil, so services it
+// doesn't cover fall back to the environment.
+func LoadProfile(service, name string) (*Profile, error) {
+	explicit := name != ""
+	name = cmp.Or(name, os.Getenv("INTEGRA_PROFILE"))
+	if name == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(ProfilesPath())
+	if err != nil {
+		return nil, err
+	}
+	var profiles map[string]map[string]*Profile
+	if err := yaml.Unmarshal(b, &profiles); err != nil {
+		return nil, fmt.Errorf("%s: %w", ProfilesPath(), err)
+	}
+	services, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' not found in %s", name, ProfilesPath())
+	}
+	p, ok := services[service]
+	if !ok || p == nil {
+		if !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("profile '%s' has no settings for %s", name, service)
+	}
+	p.Name = name
+	p.Service = service
+	for k, v := range p.Params {
+		p.Params[k] = convertYAMLToStringMap(v)
+	}
+	return p, nil
+}
+
+// Apply sets the base URL of the service from the profile
+func (p *Profile) Apply(s Service) error {
+	if p.BaseURL != "" {
+		s.SetBaseURL(p.BaseURL)
+		return nil
+	}
+	if p.Server != "" || len(p.ServerVars) > 0 {
+		return SelectServer(s, p.Server, p.ServerVars)
+	}
+	return nil
+}
+
+// Defaults returns a copy of in with the profile params added
+// for any parameters of the operation that are not already set
+func (p *Profile) Defaults(op Operation, in map[string]any) map[string]any {
+	out := make(map[string]any)
+	for k, v := range in {
+		out[k] = v
+	}
+	for _, param := range op.Parameters() {
+		if _, ok := out[param.Name()]; ok {
+			continue
+		}
+		if v, ok := p.Params[param.Name()]; ok {
+			out[param.Name()] = v
+		}
+	}
+	return out
+}
+
+// Authorize sets the credentials of the profile on a request
+func (p *Profile) Authorize(req *http.Request) {
+	token := p.Token
+	if p.TokenEnv != "" {
+		token = cmp.Or(os.Getenv(p.TokenEnv), token)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+}
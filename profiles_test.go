@@ -0,0 +1,78 @@
+package integra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testProfiles = `
+work:
+  test:
+    token: work-token
+    baseURL: https://ghe.example.com/api/v3
+    params:
+      id: 42
+personal:
+  test:
+    tokenEnv: TEST_PERSONAL_TOKEN
+`
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	if err := os.WriteFile(path, []byte(testProfiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("INTEGRA_PROFILES", path)
+	t.Setenv("INTEGRA_PROFILE", "")
+	t.Setenv("TEST_PERSONAL_TOKEN", "personal-token")
+
+	p, err := LoadProfile("test", "")
+	if p != nil || err != nil {
+		t.Fatalf("LoadProfile with no name = (%v, %v); want (nil, nil)", p, err)
+	}
+	if _, err := LoadProfile("test", "missing"); err == nil {
+		t.Errorf("expected error for missing profile")
+	}
+	if _, err := LoadProfile("other", "work"); err == nil {
+		t.Errorf("expected error for service missing from profile")
+	}
+
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "user", "get")
+
+	p, err = LoadProfile("test", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Apply(s); err != nil {
+		t.Fatal(err)
+	}
+	req, err := MakeRequest(op, p.Defaults(op, map[string]any{"fields": "name"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Authorize(req)
+	if req.URL.String() != "https://ghe.example.com/api/v3/users/42?fields=name" {
+		t.Errorf("URL = %q", req.URL.String())
+	}
+	if req.Header.Get("Authorization") != "Bearer work-token" {
+		t.Errorf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+
+	t.Setenv("INTEGRA_PROFILE", "personal")
+	p, err = LoadProfile("test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Authorize(req)
+	if req.Header.Get("Authorization") != "Bearer personal-token" {
+		t.Errorf("Authorization = %q", req.Header.Get("Authorization"))
+	}
+
+	// services missing from INTEGRA_PROFILE fall back to the environment
+	p, err = LoadProfile("other", "")
+	if p != nil || err != nil {
+		t.Errorf("LoadProfile for service missing from INTEGRA_PROFILE = (%v, %v); want (nil, nil)", p, err)
+	}
+}
@@ -0,0 +1,221 @@
+package integra
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// sampleMaxDepth limits sampled values of recursive schemas
+const sampleMaxDepth = 5
+
+var sampleWords = []string{
+	"alpha", "bravo", "cedar", "delta", "ember", "falcon", "garnet", "harbor",
+	"indigo", "juniper", "kestrel", "lumen", "meadow", "nimbus", "orchid", "pepper",
+	"quartz", "raven", "sierra", "tundra", "umber", "violet", "willow", "zephyr",
+}
+
+var sampleNames = []string{
+	"Ada", "Grace", "Alan", "Barbara", "Dennis", "Edsger", "Frances", "Hedy",
+	"Ken", "Linus", "Margaret", "Radia", "Rob", "Sophie", "Tim", "Whitfield",
+}
+
+// Sample returns a realistic instance of a schema as JSON data. Formats,
+// enums, constants, numeric and length constraints, nullability and
+// variants are honoured. The same seed always produces the same value.
+func Sample(s Schema, seed int64) any {
+	g := &sampler{rand: rand.New(rand.NewSource(seed))}
+	return g.value(s, 0)
+}
+
+type sampler struct {
+	rand *rand.Rand
+}
+
+func (g *sampler) value(s Schema, depth int) any {
+	if s == nil || depth > sampleMaxDepth {
+		return nil
+	}
+	if s.Nullable() && g.rand.Intn(5) == 0 {
+		return nil
+	}
+	if c := s.Const(); c != "" {
+		if v, ok := parseEnumValue(s, c); ok {
+			return v
+		}
+	}
+	if enum := s.Enum(); len(enum) > 0 {
+		if v, ok := parseEnumValue(s, enum[g.rand.Intn(len(enum))]); ok {
+			return v
+		}
+	}
+	if variants := append(s.OneOf(), s.AnyOf()...); len(variants) > 0 {
+		return g.value(variants[g.rand.Intn(len(variants))], depth+1)
+	}
+	switch effectiveType(s) {
+	case "object":
+		return g.object(s, depth)
+	case "array":
+		return g.array(s, depth)
+	case "integer":
+		return math.Round(g.number(s, true))
+	case "number":
+		return g.number(s, false)
+	case "boolean":
+		return g.rand.Intn(2) == 0
+	case "string":
+		return g.string(s)
+	}
+	return nil
+}
+
+func (g *sampler) object(s Schema, depth int) any {
+	obj := map[string]any{}
+	for _, prop := range s.Properties() {
+		// optional properties are left out some of the time
+		if !prop.Required() && g.rand.Intn(4) == 0 {
+			continue
+		}
+		obj[prop.Name()] = g.value(prop, depth+1)
+	}
+	if extra := s.AdditionalProperties(); extra != nil && len(s.Properties()) == 0 {
+		for i := 0; i < 1+g.rand.Intn(2); i++ {
+			obj[g.word()] = g.value(extra, depth+1)
+		}
+	}
+	return obj
+}
+
+func (g *sampler) array(s Schema, depth int) any {
+	min, max := 1, 3
+	if s.MinItems() != nil {
+		min = *s.MinItems()
+		if max < min {
+			max = min + 2
+		}
+	}
+	if s.MaxItems() != nil {
+		max = *s.MaxItems()
+		if min > max {
+			min = max
+		}
+	}
+	n := min + g.rand.Intn(max-min+1)
+	items := []any{}
+	seen := map[string]bool{}
+	for attempts := 0; len(items) < n && attempts < n*10; attempts++ {
+		item := g.value(s.Items(), depth+1)
+		if s.UniqueItems() {
+			key := fmt.Sprint(item)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (g *sampler) number(s Schema, integer bool) float64 {
+	min, max := 0.0, 1000.0
+	if integer {
+		min = 1
+	}
+	if s.Minimum() != nil {
+		min = *s.Minimum()
+		if max < min {
+			max = min + 1000
+		}
+	}
+	if s.Maximum() != nil {
+		max = *s.Maximum()
+		if min > max {
+			min = max - 1000
+		}
+	}
+	if integer {
+		min, max = math.Ceil(min), math.Floor(max)
+	}
+	v := min + g.rand.Float64()*(max-min)
+	if m := s.MultipleOf(); m != nil && *m > 0 {
+		v = math.Ceil(v / *m) * *m
+		if v > max {
+			v -= *m
+		}
+	} else if !integer {
+		v = math.Round(v*100) / 100
+	}
+	return v
+}
+
+func (g *sampler) string(s Schema) string {
+	switch s.Format() {
+	case "date-time":
+		return g.time().Format(time.RFC3339)
+	case "date":
+		return g.time().Format(time.DateOnly)
+	case "time":
+		return g.time().Format(time.TimeOnly)
+	case "email":
+		return fmt.Sprintf("%s.%s@example.com", strings.ToLower(g.name()), g.word())
+	case "uri", "url":
+		return fmt.Sprintf("https://example.com/%s/%d", g.word(), g.rand.Intn(1000))
+	case "hostname":
+		return fmt.Sprintf("%s.example.com", g.word())
+	case "uuid":
+		b := make([]byte, 16)
+		g.rand.Read(b)
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", 1+g.rand.Intn(254))
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", 1+g.rand.Intn(0xffff))
+	case "byte":
+		return base64.StdEncoding.EncodeToString([]byte(g.word()))
+	}
+	if ex := s.Example(); ex != "" && s.Pattern() == "" {
+		return ex
+	}
+
+	var str string
+	name := strings.ToLower(s.Name())
+	switch {
+	case strings.Contains(name, "email"):
+		str = fmt.Sprintf("%s@example.com", strings.ToLower(g.name()))
+	case strings.Contains(name, "url") || strings.Contains(name, "uri"):
+		str = fmt.Sprintf("https://example.com/%s", g.word())
+	case strings.Contains(name, "name"):
+		str = g.name()
+	default:
+		str = g.word() + "-" + g.word()
+	}
+
+	if min := s.MinLength(); min != nil {
+		for len(str) < *min {
+			str += "-" + g.word()
+		}
+	}
+	if max := s.MaxLength(); max != nil && len(str) > *max {
+		str = str[:*max]
+	}
+	return str
+}
+
+func (g *sampler) time() time.Time {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return base.Add(time.Duration(g.rand.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+}
+
+func (g *sampler) word() string {
+	return sampleWords[g.rand.Intn(len(sampleWords))]
+}
+
+func (g *sampler) name() string {
+	return sampleNames[g.rand.Intn(len(sampleNames))]
+}
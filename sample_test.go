@@ -0,0 +1,136 @@
+package integra
+
+import (
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+const testOpenAPISample = `
+openapi: 3.1.0
+info:
+  title: Test
+  version: "1.0"
+paths:
+  /events/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Event"
+components:
+  schemas:
+    Event:
+      type: object
+      required: [id, created, organizer, link, status, seats, price, tags, note, target]
+      properties:
+        id:
+          type: string
+          format: uuid
+        created:
+          type: string
+          format: date-time
+        organizer:
+          type: string
+          format: email
+        link:
+          type: string
+          format: uri
+        status:
+          type: string
+          enum: [confirmed, tentative, cancelled]
+        seats:
+          type: integer
+          minimum: 10
+          maximum: 20
+        price:
+          type: number
+          minimum: 0
+          maximum: 5
+          multipleOf: 0.5
+        tags:
+          type: array
+          minItems: 2
+          maxItems: 4
+          items:
+            type: string
+            maxLength: 6
+        note:
+          type: [string, "null"]
+        target:
+          oneOf:
+            - type: object
+              required: [kind]
+              properties:
+                kind:
+                  const: room
+            - type: object
+              required: [kind]
+              properties:
+                kind:
+                  const: call
+`
+
+func TestSample(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPISample)
+	schema := testOperation(t, s, "event", "get").Output()
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	sawNull := false
+	for seed := int64(0); seed < 50; seed++ {
+		v, ok := Sample(schema, seed).(map[string]any)
+		if !ok {
+			t.Fatalf("sample is not an object: %v", v)
+		}
+		if !reflect.DeepEqual(v, Sample(schema, seed)) {
+			t.Fatalf("sample with seed %d is not deterministic", seed)
+		}
+		if !uuid.MatchString(v["id"].(string)) {
+			t.Errorf("id is not a uuid: %v", v["id"])
+		}
+		if _, err := time.Parse(time.RFC3339, v["created"].(string)); err != nil {
+			t.Errorf("created: %v", err)
+		}
+		if _, err := mail.ParseAddress(v["organizer"].(string)); err != nil {
+			t.Errorf("organizer: %v", err)
+		}
+		if u, err := url.Parse(v["link"].(string)); err != nil || u.Scheme == "" {
+			t.Errorf("link is not a URI: %v", v["link"])
+		}
+		switch v["status"] {
+		case "confirmed", "tentative", "cancelled":
+		default:
+			t.Errorf("status not in enum: %v", v["status"])
+		}
+		if seats := v["seats"].(float64); seats < 10 || seats > 20 || seats != float64(int(seats)) {
+			t.Errorf("seats out of range: %v", seats)
+		}
+		if price := v["price"].(float64); price < 0 || price > 5 || price*2 != float64(int(price*2)) {
+			t.Errorf("price out of range or not a multiple: %v", price)
+		}
+		tags := v["tags"].([]any)
+		if len(tags) < 2 || len(tags) > 4 {
+			t.Errorf("tags has %d items", len(tags))
+		}
+		for _, tag := range tags {
+			if len(tag.(string)) > 6 {
+				t.Errorf("tag too long: %v", tag)
+			}
+		}
+		if v["note"] == nil {
+			sawNull = true
+		}
+		kind := v["target"].(map[string]any)["kind"]
+		if kind != "room" && kind != "call" {
+			t.Errorf("target kind not a variant: %v", kind)
+		}
+	}
+	if !sawNull {
+		t.Errorf("nullable note was never null")
+	}
+}
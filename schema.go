@@ -38,15 +38,35 @@ func (s *emptySchema) ReadOnly() bool {
 	return false
 }
 
+func (s *emptySchema) WriteOnly() bool {
+	return false
+}
+
 func (s *emptySchema) Required() bool {
 	return false
 }
 
+func (s *emptySchema) Deprecated() bool {
+	return false
+}
+
 func (s *emptySchema) Format() string {
 	return ""
 }
 
-func (s *emptySchema) Minimum() *int {
+func (s *emptySchema) Pattern() string {
+	return ""
+}
+
+func (s *emptySchema) Minimum() *float64 {
+	return nil
+}
+
+func (s *emptySchema) Maximum() *float64 {
+	return nil
+}
+
+func (s *emptySchema) MultipleOf() *float64 {
 	return nil
 }
 
@@ -58,6 +78,18 @@ func (s *emptySchema) MaxLength() *int {
 	return nil
 }
 
+func (s *emptySchema) MinItems() *int {
+	return nil
+}
+
+func (s *emptySchema) MaxItems() *int {
+	return nil
+}
+
+func (s *emptySchema) UniqueItems() bool {
+	return false
+}
+
 func (s *emptySchema) Default() string {
 	return ""
 }
@@ -70,6 +102,14 @@ func (s *emptySchema) Example() string {
 	return ""
 }
 
+func (s *emptySchema) Const() string {
+	return ""
+}
+
+func (s *emptySchema) AllOf() []Schema {
+	return nil
+}
+
 func (s *emptySchema) AnyOf() []Schema {
 	return nil
 }
@@ -78,6 +118,14 @@ func (s *emptySchema) OneOf() []Schema {
 	return nil
 }
 
+func (s *emptySchema) PrefixItems() []Schema {
+	return nil
+}
+
+func (s *emptySchema) Discriminator() *Discriminator {
+	return nil
+}
+
 func (s *emptySchema) Properties() []Schema {
 	return nil
 }
@@ -85,3 +133,7 @@ func (s *emptySchema) Properties() []Schema {
 func (s *emptySchema) Property(name string) (Schema, error) {
 	return nil, nil
 }
+
+func (s *emptySchema) AdditionalProperties() Schema {
+	return nil
+}
@@ -0,0 +1,316 @@
+package integra
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+)
+
+// Selector identifies part of a service API, written as
+//
+//	service[.resource[.operation[.schema path...]]][@version]
+//
+// like github.repo.get or digitalocean.droplet.get.networks.v4@2.0.
+// The schema path selects into the output of the operation, or into
+// the input if it starts with "in". Parts may be globs, like
+// github.repo*.list, for matching many operations.
+type Selector struct {
+	Service   string
+	Version   string
+	Resource  string
+	Operation string
+	Schema    []string
+}
+
+// ParseSelector parses a selector string
+func ParseSelector(s string) (Selector, error) {
+	selector, version := SplitSelectorVersion(strings.TrimSpace(s))
+	if selector == "" {
+		return Selector{}, fmt.Errorf("empty selector")
+	}
+	parts := strings.Split(selector, ".")
+	for _, part := range parts {
+		if part == "" {
+			return Selector{}, fmt.Errorf("invalid selector '%s': empty part", s)
+		}
+	}
+	sel := Selector{Service: parts[0], Version: version}
+	if len(parts) > 1 {
+		sel.Resource = parts[1]
+	}
+	if len(parts) > 2 {
+		sel.Operation = parts[2]
+	}
+	if len(parts) > 3 {
+		sel.Schema = parts[3:]
+	}
+	return sel, nil
+}
+
+// String returns the selector in the form parsed by ParseSelector
+func (sel Selector) String() string {
+	parts := []string{sel.Service}
+	if sel.Resource != "" {
+		parts = append(parts, sel.Resource)
+		if sel.Operation != "" {
+			parts = append(parts, sel.Operation)
+			parts = append(parts, sel.Schema...)
+		}
+	}
+	s := strings.Join(parts, ".")
+	if sel.Version != "" {
+		s += "@" + sel.Version
+	}
+	return s
+}
+
+// IsGlob reports whether any part of the selector is a glob pattern
+func (sel Selector) IsGlob() bool {
+	return slices.ContainsFunc(append([]string{sel.Service, sel.Resource, sel.Operation}, sel.Schema...), func(part string) bool {
+		return strings.ContainsAny(part, "*?[")
+	})
+}
+
+// Match reports whether a selector matches the selector as a pattern.
+// Each part is matched separately, where * matches any part of a name,
+// and parts the pattern leaves out match anything, so github matches
+// every operation of github.
+func (sel Selector) Match(target Selector) bool {
+	if sel.Version != "" && sel.Version != target.Version {
+		return false
+	}
+	match := func(pattern, name string) bool {
+		if pattern == "" {
+			return true
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}
+	if !match(sel.Service, target.Service) || !match(sel.Resource, target.Resource) || !match(sel.Operation, target.Operation) {
+		return false
+	}
+	if len(sel.Schema) > len(target.Schema) {
+		return false
+	}
+	for i, pattern := range sel.Schema {
+		if !match(pattern, target.Schema[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchOperation reports whether an operation matches the selector as a pattern
+func (sel Selector) MatchOperation(op Operation) bool {
+	r := op.Resource()
+	return sel.Match(Selector{
+		Service:   r.Service().Name(),
+		Version:   sel.Version,
+		Resource:  r.Name(),
+		Operation: op.Name(),
+	})
+}
+
+// Operations returns the operations of a service matching the selector as a pattern
+func (sel Selector) Operations(s Service) (ops []Operation) {
+	for _, r := range s.Resources() {
+		for _, op := range r.Operations() {
+			if sel.MatchOperation(op) {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+// LoadService loads the service of the selector
+func (sel Selector) LoadService() (Service, error) {
+	return LoadService(sel.Service, sel.Version)
+}
+
+// SelectorError is returned when part of a selector can't be resolved
+type SelectorError struct {
+	Selector    Selector
+	Kind        string
+	Name        string
+	Suggestions []string
+}
+
+func (e *SelectorError) Error() string {
+	msg := fmt.Sprintf("%s '%s' not found in %s", e.Kind, e.Name, e.Selector)
+	switch len(e.Suggestions) {
+	case 0:
+		return msg
+	case 1:
+		return fmt.Sprintf("%s, did you mean '%s'?", msg, e.Suggestions[0])
+	default:
+		return fmt.Sprintf("%s, did you mean one of '%s'?", msg, strings.Join(e.Suggestions, "', '"))
+	}
+}
+
+// ResolveResource returns the resource of the selector from a
+// service. Names in another case, like repo-hook for repoHook,
+// are accepted. Otherwise the error suggests similar names.
+func (sel Selector) ResolveResource(s Service) (Resource, error) {
+	if sel.Resource == "" {
+		return nil, fmt.Errorf("missing resource in selector '%s'", sel)
+	}
+	if r, err := s.Resource(sel.Resource); err == nil {
+		return r, nil
+	}
+	var names []string
+	for _, r := range s.Resources() {
+		names = append(names, r.Name())
+	}
+	name, suggestions := resolveName(sel.Resource, names)
+	if name == "" {
+		return nil, &SelectorError{Selector: sel, Kind: "resource", Name: sel.Resource, Suggestions: suggestions}
+	}
+	return s.Resource(name)
+}
+
+// ResolveOperation returns the operation of the selector from a service
+func (sel Selector) ResolveOperation(s Service) (Operation, error) {
+	r, err := sel.ResolveResource(s)
+	if err != nil {
+		return nil, err
+	}
+	if sel.Operation == "" {
+		return nil, fmt.Errorf("missing operation in selector '%s'", sel)
+	}
+	if op, err := r.Operation(sel.Operation); err == nil {
+		return op, nil
+	}
+	var names []string
+	for _, op := range r.Operations() {
+		names = append(names, op.Name())
+	}
+	name, suggestions := resolveName(sel.Operation, names)
+	if name == "" {
+		return nil, &SelectorError{Selector: sel, Kind: "operation", Name: sel.Operation, Suggestions: suggestions}
+	}
+	return r.Operation(name)
+}
+
+// ResolveSchema returns the schema selected by the schema path from
+// an operation, passing through the items of arrays along the way
+func (sel Selector) ResolveSchema(op Operation) (Schema, error) {
+	schema := op.Output()
+	path := sel.Schema
+	if len(path) > 0 {
+		switch path[0] {
+		case "in":
+			schema, path = op.Input(), path[1:]
+		case "out":
+			path = path[1:]
+		}
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("%s has no schema", OperationSelector(op))
+	}
+	for _, part := range path {
+		if schema.Type() == "array" && schema.Items() != nil {
+			schema = schema.Items()
+		}
+		var names []string
+		var next Schema
+		for _, prop := range schema.Properties() {
+			names = append(names, prop.Name())
+			if prop.Name() == part {
+				next = prop
+			}
+		}
+		if next == nil {
+			name, suggestions := resolveName(part, names)
+			if name == "" {
+				return nil, &SelectorError{Selector: sel, Kind: "property", Name: part, Suggestions: suggestions}
+			}
+			for _, prop := range schema.Properties() {
+				if prop.Name() == name {
+					next = prop
+				}
+			}
+		}
+		schema = next
+	}
+	return schema, nil
+}
+
+// maxSuggestions limits the names suggested for an unknown name
+const maxSuggestions = 3
+
+// resolveName finds the name among names that is the same as name in
+// another case. Otherwise it returns names that are similar to suggest.
+func resolveName(name string, names []string) (string, []string) {
+	variants := NameVariants(name)
+	for _, n := range names {
+		if slices.Contains(variants, n) {
+			return n, nil
+		}
+	}
+
+	// same words, ignoring case and plurals
+	keys := map[string]bool{}
+	for _, v := range variants {
+		key := nameKey(v)
+		keys[key] = true
+		keys[nameKey(inflection.Singular(v))] = true
+		keys[nameKey(inflection.Plural(v))] = true
+	}
+	var suggestions []string
+	for _, n := range names {
+		if keys[nameKey(n)] {
+			suggestions = append(suggestions, n)
+		}
+	}
+
+	// then by spelling
+	key := nameKey(name)
+	for _, n := range names {
+		if slices.Contains(suggestions, n) {
+			continue
+		}
+		other := nameKey(n)
+		if editDistance(key, other) <= max(1, len(key)/4) || (len(key) > 2 && strings.HasPrefix(other, key)) {
+			suggestions = append(suggestions, n)
+		}
+	}
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return "", suggestions
+}
+
+// nameKey returns a name lowercased without separators, for comparing names
+func nameKey(name string) string {
+	return strings.NewReplacer("_", "", "-", "", "~", "").Replace(strings.ToLower(name))
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions of adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
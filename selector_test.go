@@ -0,0 +1,132 @@
+package integra
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Selector
+	}{
+		{"github", Selector{Service: "github"}},
+		{"github@v3", Selector{Service: "github", Version: "v3"}},
+		{"github.repo", Selector{Service: "github", Resource: "repo"}},
+		{"github.repo.get", Selector{Service: "github", Resource: "repo", Operation: "get"}},
+		{"github.repo.~list@v3", Selector{Service: "github", Version: "v3", Resource: "repo", Operation: "~list"}},
+		{"github.repo.get.owner.login", Selector{Service: "github", Resource: "repo", Operation: "get", Schema: []string{"owner", "login"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := ParseSelector(test.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Service != test.want.Service || got.Version != test.want.Version ||
+				got.Resource != test.want.Resource || got.Operation != test.want.Operation ||
+				!slices.Equal(got.Schema, test.want.Schema) {
+				t.Fatalf("got %#v; want %#v", got, test.want)
+			}
+			if got.String() != test.in {
+				t.Fatalf("String() = %q; want %q", got.String(), test.in)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "github..get", "github.repo."} {
+		if _, err := ParseSelector(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		selector string
+		want     bool
+	}{
+		{"github", "github.repo.get", true},
+		{"github.*", "github.repo.get", true},
+		{"github.repo*.list", "github.repoHook.list", true},
+		{"github.repo*.list", "github.repo.get", false},
+		{"github.*.delete", "github.issue.delete", true},
+		{"github.*.delete", "gitlab.issue.delete", false},
+		{"github.repo.get@v3", "github.repo.get", false},
+		{"github.repo.get.owner", "github.repo.get", false},
+	}
+	for _, test := range tests {
+		if got := MatchSelector(test.pattern, test.selector); got != test.want {
+			t.Errorf("MatchSelector(%q, %q) = %v; want %v", test.pattern, test.selector, got, test.want)
+		}
+	}
+}
+
+func TestSelectorResolve(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+
+	sel, _ := ParseSelector("test.pet.get")
+	op, err := sel.ResolveOperation(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if OperationSelector(op) != "test.pet.get" {
+		t.Fatalf("unexpected operation: %s", OperationSelector(op))
+	}
+
+	sel, _ = ParseSelector("test.pet.gte")
+	_, err = sel.ResolveOperation(s)
+	var serr *SelectorError
+	if !errors.As(err, &serr) {
+		t.Fatalf("expected SelectorError, got %v", err)
+	}
+	if serr.Kind != "operation" || !slices.Equal(serr.Suggestions, []string{"get"}) {
+		t.Fatalf("unexpected error: %v", serr)
+	}
+
+	sel, _ = ParseSelector("test.pet.list.nmae")
+	op, err = sel.ResolveOperation(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sel.ResolveSchema(op)
+	if !errors.As(err, &serr) || !slices.Equal(serr.Suggestions, []string{"name"}) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sel, _ = ParseSelector("test.pet.list.status")
+	schema, err := sel.ResolveSchema(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Name() != "status" || schema.Type() != "string" {
+		t.Fatalf("unexpected schema: %s %s", schema.Name(), schema.Type())
+	}
+
+	sel, _ = ParseSelector("test.*.delete")
+	ops := sel.Operations(s)
+	if len(ops) != 1 || OperationSelector(ops[0]) != "test.pet.delete" {
+		t.Fatalf("unexpected operations: %v", ops)
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	names := []string{"repo", "repoHook", "issue", "issueComment"}
+	if name, _ := resolveName("repo-hook", names); name != "repoHook" {
+		t.Errorf("resolveName(repo-hook) = %q; want repoHook", name)
+	}
+	if name, _ := resolveName("repos", names); name != "repo" {
+		t.Errorf("resolveName(repos) = %q; want repo", name)
+	}
+	if _, suggestions := resolveName("isue", names); !slices.Equal(suggestions, []string{"issue"}) {
+		t.Errorf("resolveName(isue) suggested %v", suggestions)
+	}
+	if _, suggestions := resolveName("isseuComment", names); !slices.Equal(suggestions, []string{"issueComment"}) {
+		t.Errorf("resolveName(isseuComment) suggested %v", suggestions)
+	}
+	if _, suggestions := resolveName("zebra", names); len(suggestions) != 0 {
+		t.Errorf("resolveName(zebra) suggested %v", suggestions)
+	}
+}
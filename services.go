@@ -4,10 +4,15 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"slices"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v2"
@@ -56,17 +61,11 @@ func ServiceClientCredentials(service string) (string, string) {
 func LoadService(name, version string) (Service, error) {
 	serviceDir := strings.ReplaceAll(name, "-", "/")
 
-	b, err := fs.ReadFile(services, path.Join("services", serviceDir, "meta.yaml"))
+	meta, err := loadServiceMeta(name)
 	if err != nil {
 		return nil, err
 	}
 
-	var yamlData map[any]any
-	if err := yaml.Unmarshal(b, &yamlData); err != nil {
-		return nil, err
-	}
-	meta := jsonaccess.New(convertYAMLToStringMap(yamlData))
-
 	if version == "" {
 		version = jsonaccess.MustAs[string](meta.Get("latest"))
 	}
@@ -79,7 +78,7 @@ func LoadService(name, version string) (Service, error) {
 	for _, info := range dir {
 		switch info.Name() {
 		case "openapi.json":
-			b, err = fs.ReadFile(services, path.Join("services", serviceDir, version, "openapi.json"))
+			b, err := fs.ReadFile(services, path.Join("services", serviceDir, version, "openapi.json"))
 			if err != nil {
 				return nil, err
 			}
@@ -96,7 +95,7 @@ func LoadService(name, version string) (Service, error) {
 			return &openapiService{name: name, schema: root, meta: meta}, nil
 
 		case "openapi.yaml":
-			b, err = fs.ReadFile(services, path.Join("services", serviceDir, version, "openapi.yaml"))
+			b, err := fs.ReadFile(services, path.Join("services", serviceDir, version, "openapi.yaml"))
 			if err != nil {
 				return nil, err
 			}
@@ -114,7 +113,7 @@ func LoadService(name, version string) (Service, error) {
 			return &openapiService{name: name, schema: root, meta: meta}, nil
 
 		case "googleapi.json":
-			b, err = fs.ReadFile(services, path.Join("services", serviceDir, version, "googleapi.json"))
+			b, err := fs.ReadFile(services, path.Join("services", serviceDir, version, "googleapi.json"))
 			if err != nil {
 				return nil, err
 			}
@@ -135,9 +134,143 @@ func LoadService(name, version string) (Service, error) {
 	return nil, fmt.Errorf("no schema found for %s@%s", name, version)
 }
 
+// loadServiceMeta loads the meta.yaml of a service
+func loadServiceMeta(name string) (*jsonaccess.Value, error) {
+	serviceDir := strings.ReplaceAll(name, "-", "/")
+	b, err := fs.ReadFile(services, path.Join("services", serviceDir, "meta.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var yamlData map[any]any
+	if err := yaml.Unmarshal(b, &yamlData); err != nil {
+		return nil, err
+	}
+	return jsonaccess.New(convertYAMLToStringMap(yamlData)), nil
+}
+
+// Expand returns the server URL with variables filled
+// in from vars, or their defaults if not in vars
+func (s Server) Expand(vars map[string]string) (string, error) {
+	u := s.URL
+	for name := range vars {
+		if _, ok := s.Variables[name]; !ok {
+			return "", fmt.Errorf("unknown server variable '%s' for %s", name, s.URL)
+		}
+	}
+	for name, variable := range s.Variables {
+		v, ok := vars[name]
+		if !ok {
+			v = variable.Default
+		}
+		if len(variable.Enum) > 0 && !slices.Contains(variable.Enum, v) {
+			return "", fmt.Errorf("server variable '%s' must be one of: %s", name, strings.Join(variable.Enum, ", "))
+		}
+		u = strings.ReplaceAll(u, fmt.Sprintf("{%s}", name), v)
+	}
+	return u, nil
+}
+
+// SelectServer selects one of the declared servers of a service by
+// index, URL or description, with variables filled from vars. If server
+// is empty the first server is used. Operations declaring their own
+// servers use their server matching the selection, or their first, so
+// selecting a server never moves them. Use SetBaseURL to override the
+// base URL of every operation.
+func SelectServer(s Service, server string, vars map[string]string) error {
+	lists := [][]Server{s.Servers()}
+	for _, r := range s.Resources() {
+		for _, op := range r.Operations() {
+			lists = append(lists, op.Servers())
+		}
+	}
+	found := false
+	known := map[string]bool{}
+	for _, servers := range lists {
+		idx := findServer(servers, server)
+		if idx < 0 {
+			continue
+		}
+		found = true
+		for name := range servers[idx].Variables {
+			known[name] = true
+		}
+		if _, err := servers[idx].Expand(serverVars(servers[idx], vars)); err != nil {
+			return err
+		}
+	}
+	if !found {
+		if len(s.Servers()) == 0 {
+			return fmt.Errorf("no servers declared for %s", s.Name())
+		}
+		return fmt.Errorf("server '%s' not found for %s", server, s.Name())
+	}
+	for name := range vars {
+		if !known[name] {
+			return fmt.Errorf("unknown server variable '%s' for server '%s'", name, server)
+		}
+	}
+
+	if selector, ok := s.(interface {
+		selectServer(server string, vars map[string]string)
+	}); ok {
+		selector.selectServer(server, vars)
+		return nil
+	}
+	// services without operation servers can use their base URL
+	servers := s.Servers()
+	idx := findServer(servers, server)
+	if idx < 0 {
+		return fmt.Errorf("server '%s' not found for %s", server, s.Name())
+	}
+	u, err := servers[idx].Expand(vars)
+	if err != nil {
+		return err
+	}
+	baseExtension := jsonaccess.AsOrZero[string](s.Meta().Get("extendBaseTo"))
+	if baseExtension != "" {
+		u, _ = url.JoinPath(u, baseExtension)
+	}
+	s.SetBaseURL(u)
+	return nil
+}
+
+// findServer returns the index of the server selected by index, URL
+// or description, the first if server is empty, or -1 if not found
+func findServer(servers []Server, server string) int {
+	if len(servers) == 0 {
+		return -1
+	}
+	if server == "" {
+		return 0
+	}
+	if idx, err := strconv.Atoi(server); err == nil {
+		if idx >= 0 && idx < len(servers) {
+			return idx
+		}
+		return -1
+	}
+	for idx, srv := range servers {
+		if srv.URL == server || srv.Description == server {
+			return idx
+		}
+	}
+	return -1
+}
+
+// serverVars returns the variables of vars declared by a server
+func serverVars(srv Server, vars map[string]string) map[string]string {
+	declared := make(map[string]string)
+	for name, v := range vars {
+		if _, ok := srv.Variables[name]; ok {
+			declared[name] = v
+		}
+	}
+	return declared
+}
+
 func ExpandURL(u string, params map[string]any) (string, error) {
 	for k, v := range params {
-		u = strings.Replace(u, fmt.Sprintf("{%s}", k), fmt.Sprint(v), 1)
+		u = strings.Replace(u, fmt.Sprintf("{%s}", k), formString(v), 1)
 	}
 	if strings.Contains(u, "{") {
 		return "", fmt.Errorf("parameters not sufficient to expand URL: %s", u)
@@ -145,7 +278,15 @@ func ExpandURL(u string, params map[string]any) (string, error) {
 	return u, nil
 }
 
+// MakeRequest builds the request for an operation with input, using
+// the token for its service from the environment if there is one
 func MakeRequest(op Operation, in map[string]any) (*http.Request, error) {
+	return makeRequest(op, in, true)
+}
+
+// makeRequest builds the request for an operation, with the
+// environment token if envAuth is set
+func makeRequest(op Operation, in map[string]any, envAuth bool) (*http.Request, error) {
 
 	var required []string
 	for _, p := range op.Parameters() {
@@ -167,40 +308,72 @@ func MakeRequest(op Operation, in map[string]any) (*http.Request, error) {
 	}
 
 	params := make(map[string]any)
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
 	for _, p := range op.Parameters() {
 		v, ok := data[p.Name()]
-		if ok {
+		if !ok {
+			continue
+		}
+		delete(data, p.Name())
+		switch p.In() {
+		case "query":
+			addFormValue(query, p.Name(), v)
+		case "header":
+			header.Set(p.Name(), formString(v))
+		case "cookie":
+			cookies = append(cookies, &http.Cookie{Name: p.Name(), Value: formString(v)})
+		default:
 			params[p.Name()] = v
-			delete(data, p.Name())
 		}
 	}
 	u, err := ExpandURL(op.URL(), params)
 	if err != nil {
 		return nil, err
 	}
+	if len(query) > 0 {
+		uu, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		q := uu.Query()
+		for k, v := range query {
+			q[k] = append(q[k], v...)
+		}
+		uu.RawQuery = q.Encode()
+		u = uu.String()
+	}
 
-	// var body io.Reader
-	// if slices.Contains([]string{"create", "set", "update"}, op.Name()) {
-	// 	b, err := json.Marshal(params)
-	// 	if err != nil {
-	// 		log.Fatal(err)
-	// 	}
-	// 	body = bytes.NewBuffer(b)
-	// }
+	// whatever isn't a parameter is sent as the body
+	var body io.Reader
+	var contentType string
+	inputs := op.Inputs()
+	if len(inputs) > 0 && len(data) > 0 {
+		mediaType := requestMediaType(slices.Sorted(maps.Keys(inputs)), data)
+		body, contentType, err = encodeBody(mediaType, data)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// todo: need to populate url since implementing new model
-	req, err := http.NewRequest(strings.ToUpper(op.Method()), u, nil)
+	req, err := http.NewRequest(strings.ToUpper(op.Method()), u, body)
 	if err != nil {
 		return nil, err
 	}
+	req.Header = header
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
 
 	// todo: alternative schemes
-	token := ServiceToken(op.Resource().Service().Name())
-	if token != "" {
+	if token := ServiceToken(op.Resource().Service().Name()); token != "" && envAuth {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	return req, nil
 }
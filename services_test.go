@@ -0,0 +1,320 @@
+package integra
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOpenAPIRequests = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+servers:
+  - url: https://api.example.com
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: array
+        - name: X-Trace
+          in: header
+          schema:
+            type: string
+      responses: {}
+    patch:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+      responses: {}
+  /users/{id}/avatar:
+    put:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+          multipart/form-data:
+            schema:
+              type: object
+      responses: {}
+  /uploads:
+    post:
+      requestBody:
+        content:
+          application/octet-stream: {}
+      responses: {}
+`
+
+func testOperation(t *testing.T, s *openapiService, resource, op string) Operation {
+	t.Helper()
+	r, err := s.Resource(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := r.Operation(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestMakeRequestParams(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "user", "get")
+
+	req, err := MakeRequest(op, map[string]any{
+		"id":      float64(8043964),
+		"fields":  []any{"name", "email"},
+		"X-Trace": "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "https://api.example.com/users/8043964?fields=name&fields=email"
+	if req.URL.String() != expected {
+		t.Errorf("URL = %q; want %q", req.URL.String(), expected)
+	}
+	if req.Header.Get("X-Trace") != "abc" {
+		t.Errorf("X-Trace header = %q; want abc", req.Header.Get("X-Trace"))
+	}
+	if req.Body != nil {
+		t.Errorf("expected no body")
+	}
+}
+
+func TestMakeRequestForm(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "user", "update")
+
+	req, err := MakeRequest(op, map[string]any{"id": "1", "name": "jeff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	b, _ := io.ReadAll(req.Body)
+	if string(b) != "name=jeff" {
+		t.Errorf("body = %q; want name=jeff", b)
+	}
+}
+
+func TestMakeRequestMultipart(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "userAvatar", "set")
+
+	// without files, JSON is preferred
+	req, err := MakeRequest(op, map[string]any{"id": "1", "caption": "me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+
+	// strings are never read as files
+	req, err = MakeRequest(op, map[string]any{"id": "1", "caption": "@octocat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+
+	avatar := filepath.Join(t.TempDir(), "avatar.png")
+	if err := os.WriteFile(avatar, []byte("PNG"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(avatar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	req, err = MakeRequest(op, map[string]any{"id": "1", "caption": "me", "file": f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("Content-Type = %q; want multipart/form-data", req.Header.Get("Content-Type"))
+	}
+	form, err := multipart.NewReader(req.Body, params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["caption"][0] != "me" {
+		t.Errorf("caption = %v; want me", form.Value["caption"])
+	}
+	if fh := form.File["file"]; len(fh) != 1 || fh[0].Filename != "avatar.png" {
+		t.Errorf("file = %v; want avatar.png part", fh)
+	}
+}
+
+func TestMakeRequestRaw(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIRequests)
+	op := testOperation(t, s, "upload", "create")
+
+	req, err := MakeRequest(op, map[string]any{"data": strings.NewReader("raw bytes")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/octet-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	b, _ := io.ReadAll(req.Body)
+	if string(b) != "raw bytes" {
+		t.Errorf("body = %q; want raw bytes", b)
+	}
+
+	if _, err := MakeRequest(op, map[string]any{"a": "1", "b": "2"}); err == nil {
+		t.Errorf("expected error for multiple raw body values")
+	}
+}
+
+const testOpenAPIServers = `
+openapi: 3.0.3
+info:
+  title: Test
+  version: "1.0"
+servers:
+  - url: https://{region}.api.example.com
+    description: production
+    variables:
+      region:
+        default: nyc
+        enum: [nyc, sfo]
+  - url: https://sandbox.example.com
+    description: sandbox
+paths:
+  /things:
+    get:
+      responses: {}
+  /files:
+    get:
+      servers:
+        - url: https://files.example.com
+        - url: https://eu.files.example.com
+          description: eu
+      responses: {}
+`
+
+func TestSelectServer(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIServers)
+	if s.BaseURL() != "https://nyc.api.example.com" {
+		t.Errorf("BaseURL() = %q; want default server expanded", s.BaseURL())
+	}
+	files := testOperation(t, s, "file", "list")
+	if files.URL() != "https://files.example.com/files" {
+		t.Errorf("files URL = %q; want operation server", files.URL())
+	}
+
+	tests := []struct {
+		server   string
+		vars     map[string]string
+		expected string
+	}{
+		{"", map[string]string{"region": "sfo"}, "https://sfo.api.example.com"},
+		{"1", nil, "https://sandbox.example.com"},
+		{"sandbox", nil, "https://sandbox.example.com"},
+		{"", map[string]string{"region": "ams"}, ""},
+		{"", map[string]string{"zone": "a"}, ""},
+		{"staging", nil, ""},
+	}
+	for _, test := range tests {
+		s.SetBaseURL("")
+		err := SelectServer(s, test.server, test.vars)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("SelectServer(%q, %v) expected error", test.server, test.vars)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SelectServer(%q, %v) = %v", test.server, test.vars, err)
+			continue
+		}
+		if s.BaseURL() != test.expected {
+			t.Errorf("SelectServer(%q, %v) base = %q; want %q", test.server, test.vars, s.BaseURL(), test.expected)
+		}
+	}
+
+	// operation servers are selected from their own
+	things := testOperation(t, s, "thing", "list")
+	if err := SelectServer(s, "sandbox", nil); err != nil {
+		t.Fatal(err)
+	}
+	if things.URL() != "https://sandbox.example.com/things" || files.URL() != "https://files.example.com/files" {
+		t.Errorf("sandbox URLs = (%q, %q); want files on its own server", things.URL(), files.URL())
+	}
+	if err := SelectServer(s, "eu", nil); err != nil {
+		t.Fatal(err)
+	}
+	if things.URL() != "https://nyc.api.example.com/things" || files.URL() != "https://eu.files.example.com/files" {
+		t.Errorf("eu URLs = (%q, %q); want only files on eu", things.URL(), files.URL())
+	}
+
+	s.SetBaseURL("https://ghe.example.com/api/v3")
+	if things.URL() != "https://ghe.example.com/api/v3/things" {
+		t.Errorf("things URL = %q; want overridden base", things.URL())
+	}
+	if files.URL() != "https://ghe.example.com/api/v3/files" {
+		t.Errorf("files URL = %q; want overridden base", files.URL())
+	}
+}
+
+func TestGoogleResourceParent(t *testing.T) {
+	s, err := LoadService("google-calendar", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Resource("calendar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Parent() != nil {
+		t.Errorf("top level resource has parent %#v", r.Parent())
+	}
+}
+
+func TestGoogleOperationResponses(t *testing.T) {
+	s, err := LoadService("google-calendar", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel, _ := ParseSelector("google-calendar.event.delete")
+	op, err := sel.ResolveOperation(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := op.Responses()
+	if _, ok := responses["default"]; !ok || len(responses) != 1 {
+		t.Errorf("expected only a default response, got %v", responses)
+	}
+	if status := mockStatus(op); status != http.StatusOK {
+		t.Errorf("mockStatus = %d; want 200", status)
+	}
+}
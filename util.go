@@ -1,15 +1,20 @@
 package integra
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"log"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/jinzhu/inflection"
+	"gopkg.in/yaml.v2"
+	"tractor.dev/integra/internal/jsonaccess"
 )
 
 var acronyms = map[string]bool{
@@ -257,6 +262,45 @@ func convertYAMLToStringMap(i interface{}) interface{} {
 	return i
 }
 
+// ParseInput parses call input given as a JSON or YAML object
+func ParseInput(b []byte) (map[string]any, error) {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		var raw any
+		if yerr := yaml.Unmarshal(b, &raw); yerr != nil {
+			return nil, fmt.Errorf("input is not JSON or YAML: %w", yerr)
+		}
+		v = convertYAMLToStringMap(raw)
+	}
+	if v == nil {
+		return map[string]any{}, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("input must be an object, got %T", v)
+	}
+	return m, nil
+}
+
+// MergeInput merges override into base, recursing into
+// nested objects so overrides can set individual fields
+func MergeInput(base, override map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(override))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		bm, bok := out[k].(map[string]any)
+		om, ook := v.(map[string]any)
+		if bok && ook {
+			out[k] = MergeInput(bm, om)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
 func isLower(r rune) bool {
 	return r >= 'a' && r <= 'z'
 }
@@ -321,3 +365,157 @@ func ResourceGetter(r Resource) Operation {
 	}
 	return nil
 }
+
+// SelectVariant returns the variant of a polymorphic (oneOf/anyOf) schema
+// that describes the given data. The discriminator is used if there is one,
+// otherwise the first variant with matching properties is returned.
+func SelectVariant(s Schema, data *jsonaccess.Value) Schema {
+	variants := s.OneOf()
+	if len(variants) == 0 {
+		variants = s.AnyOf()
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+	if d := s.Discriminator(); d != nil && d.PropertyName != "" {
+		value := jsonaccess.AsOrZero[string](data.Get(d.PropertyName))
+		if value == "" {
+			return nil
+		}
+		name := cmp.Or(d.Mapping[value], value)
+		for _, v := range variants {
+			if v.Name() == name {
+				return v
+			}
+		}
+		// fall through to matching on the property value
+	}
+	for _, v := range variants {
+		if variantMatches(v, data) {
+			return v
+		}
+	}
+	return nil
+}
+
+func variantMatches(s Schema, data *jsonaccess.Value) bool {
+	props := s.Properties()
+	if len(props) == 0 {
+		return typeMatches(s.Type(), data)
+	}
+	if !typeMatches("object", data) {
+		return false
+	}
+	for _, p := range props {
+		v := data.Get(p.Name())
+		if v.IsNil() {
+			if p.Required() {
+				return false
+			}
+			continue
+		}
+		enum := p.Enum()
+		if len(enum) > 0 && !slices.Contains(enum, jsonaccess.AsOrZero[string](v)) {
+			return false
+		}
+	}
+	return true
+}
+
+func typeMatches(typ string, data *jsonaccess.Value) bool {
+	switch data.Data().(type) {
+	case map[string]any:
+		return typ == "object" || typ == ""
+	case []any:
+		return typ == "array" || typ == ""
+	case string:
+		return typ == "string" || typ == ""
+	case bool:
+		return typ == "boolean" || typ == ""
+	case float64, int:
+		return typ == "number" || typ == "integer" || typ == ""
+	case nil:
+		return typ == "null" || typ == ""
+	}
+	return typ == ""
+}
+
+// PreferredMediaType picks the first JSON media type
+// of those given, otherwise the first media type
+func PreferredMediaType(mediaTypes []string) string {
+	for _, mediaType := range mediaTypes {
+		if IsJSONMediaType(mediaType) {
+			return mediaType
+		}
+	}
+	if len(mediaTypes) == 0 {
+		return ""
+	}
+	return mediaTypes[0]
+}
+
+// IsJSONMediaType checks if a media type is JSON or uses a JSON suffix (RFC 6839)
+func IsJSONMediaType(mediaType string) bool {
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// schemaText formats the value of a schema keyword like default or
+// example as text, encoding objects and arrays as JSON
+func schemaText(v *jsonaccess.Value) string {
+	if v.IsNil() {
+		return ""
+	}
+	return formString(v.Data())
+}
+
+// schemaTexts formats the values of a list keyword like enum as text
+func schemaTexts(v *jsonaccess.Value) (texts []string) {
+	for _, item := range v.Items() {
+		if !item.IsNil() {
+			texts = append(texts, schemaText(item))
+		}
+	}
+	return
+}
+
+// parseSchemaValue converts a string value like an example
+// or default to a JSON value of the schema's type
+func parseSchemaValue(s Schema, v string) (any, bool) {
+	switch effectiveType(s) {
+	case "integer", "number":
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	case "object", "array":
+		var value any
+		err := json.Unmarshal([]byte(v), &value)
+		return value, err == nil
+	case "":
+		// without a type there's no telling how the value was formatted
+		return nil, false
+	default:
+		return v, true
+	}
+}
+
+// parseEnumValue converts an enum or const value to a JSON value
+// of the schema's type, assuming a string when there is no type
+func parseEnumValue(s Schema, v string) (any, bool) {
+	if effectiveType(s) == "" {
+		return v, true
+	}
+	return parseSchemaValue(s, v)
+}
+
+// effectiveType returns the type of a schema, treating
+// schemas without a type but with properties as objects
+func effectiveType(s Schema) string {
+	if s.Type() == "" && len(s.Properties()) > 0 {
+		return "object"
+	}
+	return s.Type()
+}
@@ -48,3 +48,49 @@ func TestNameVariants(t *testing.T) {
 		})
 	}
 }
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]any
+	}{
+		{`{"summary": "Standup", "start": {"dateTime": "2024-01-01T09:00:00Z"}}`,
+			map[string]any{"summary": "Standup", "start": map[string]any{"dateTime": "2024-01-01T09:00:00Z"}}},
+		{"summary: Standup\nattendees:\n  - email: a@example.com\n",
+			map[string]any{"summary": "Standup", "attendees": []any{map[string]any{"email": "a@example.com"}}}},
+		{"", map[string]any{}},
+	}
+	for _, test := range tests {
+		output, err := ParseInput([]byte(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("ParseInput(%q) = %v; want %v", test.input, output, test.expected)
+		}
+	}
+
+	if _, err := ParseInput([]byte(`[1, 2]`)); err == nil {
+		t.Errorf("expected error for non-object input")
+	}
+}
+
+func TestMergeInput(t *testing.T) {
+	base := map[string]any{
+		"calendarId": "primary",
+		"summary":    "Standup",
+		"start":      map[string]any{"dateTime": "2024-01-01T09:00:00Z", "timeZone": "UTC"},
+	}
+	override := map[string]any{
+		"summary": "Retro",
+		"start":   map[string]any{"dateTime": "2024-01-02T09:00:00Z"},
+	}
+	expected := map[string]any{
+		"calendarId": "primary",
+		"summary":    "Retro",
+		"start":      map[string]any{"dateTime": "2024-01-02T09:00:00Z", "timeZone": "UTC"},
+	}
+	if output := MergeInput(base, override); !reflect.DeepEqual(output, expected) {
+		t.Errorf("MergeInput() = %v; want %v", output, expected)
+	}
+}
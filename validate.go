@@ -0,0 +1,111 @@
+package integra
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// validateMaxDepth limits validation of recursive schemas
+const validateMaxDepth = 16
+
+// ValidateValue checks decoded JSON against a schema and returns the
+// problems found, each prefixed with the path to the value. Validation
+// is lenient: null is accepted anywhere and unknown properties are
+// allowed, so only values that contradict the schema are reported.
+func ValidateValue(s Schema, v any) []string {
+	var problems []string
+	validateValue(s, v, "$", 0, &problems)
+	return problems
+}
+
+func validateValue(s Schema, v any, path string, depth int, problems *[]string) {
+	if s == nil || v == nil || depth > validateMaxDepth {
+		return
+	}
+	report := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if variants := append(s.OneOf(), s.AnyOf()...); len(variants) > 0 {
+		matched := slices.ContainsFunc(variants, func(variant Schema) bool {
+			var p []string
+			validateValue(variant, v, path, depth+1, &p)
+			return len(p) == 0
+		})
+		if !matched {
+			report("does not match any of %d variants", len(variants))
+		}
+		return
+	}
+
+	switch effectiveType(s) {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			report("expected object, got %s", jsonTypeName(v))
+			return
+		}
+		for _, prop := range s.Properties() {
+			pv, present := obj[prop.Name()]
+			if !present {
+				if prop.Required() {
+					report("missing required property %s", prop.Name())
+				}
+				continue
+			}
+			validateValue(prop, pv, path+"."+prop.Name(), depth+1, problems)
+		}
+	case "array":
+		list, ok := v.([]any)
+		if !ok {
+			report("expected array, got %s", jsonTypeName(v))
+			return
+		}
+		for i, item := range list {
+			validateValue(s.Items(), item, fmt.Sprintf("%s[%d]", path, i), depth+1, problems)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			report("expected string, got %s", jsonTypeName(v))
+			return
+		}
+		if enum := s.Enum(); len(enum) > 0 && !slices.Contains(enum, str) {
+			report("%q is not one of %s", str, strings.Join(enum, ", "))
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			report("expected integer, got %s", jsonTypeName(v))
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			report("expected number, got %s", jsonTypeName(v))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report("expected boolean, got %s", jsonTypeName(v))
+		}
+	}
+}
+
+func jsonTypeName(v any) string {
+	switch vv := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if vv == math.Trunc(vv) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,266 @@
+package integra
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// WebhookConfig describes how a service delivers webhook events. It is
+// configured for a service under webhooks in meta.yaml:
+//
+//	webhooks:
+//	  signatureHeader: X-Hub-Signature-256
+//	  signatureAlgorithm: sha256
+//	  signaturePrefix: "sha256="
+//	  eventHeader: X-GitHub-Event
+//	  eventAction: .action
+//
+// Without configuration, signatures are expected in X-Hub-Signature-256
+// as a hex HMAC-SHA256 of the body prefixed with sha256=, which is used
+// by GitHub and many other providers. Without a header, other algorithms
+// use the GitHub names too, like X-Hub-Signature with sha1=. Only a plain HMAC of the body is
+// supported, so schemes signing a timestamp with the body to limit
+// replays, like Stripe's and Slack's, can't be verified.
+type WebhookConfig struct {
+	// SignatureHeader holds the HMAC of the request body
+	SignatureHeader string `json:"signatureHeader"`
+
+	// SignatureAlgorithm is sha256, sha1 or sha512
+	SignatureAlgorithm string `json:"signatureAlgorithm"`
+
+	// SignaturePrefix is trimmed from the signature, like sha256=
+	SignaturePrefix string `json:"signaturePrefix"`
+
+	// SignatureEncoding is hex or base64
+	SignatureEncoding string `json:"signatureEncoding"`
+
+	// EventHeader holds the name of the event
+	EventHeader string `json:"eventHeader"`
+
+	// EventAction selects an action from the payload with Query syntax,
+	// which is appended to the event name like issues-opened
+	EventAction string `json:"eventAction"`
+}
+
+// WebhookConfigFor returns the webhook configuration of a service
+func WebhookConfigFor(s Service) WebhookConfig {
+	var c WebhookConfig
+	if raw := s.Meta().Get("webhooks").Data(); raw != nil {
+		b, _ := json.Marshal(raw)
+		json.Unmarshal(b, &c)
+	}
+	if c.SignatureAlgorithm == "" {
+		c.SignatureAlgorithm = "sha256"
+	}
+	if c.SignatureHeader == "" {
+		// GitHub style, naming the header and prefix by algorithm
+		c.SignatureHeader = "X-Hub-Signature"
+		if c.SignatureAlgorithm != "sha1" {
+			c.SignatureHeader += "-" + strings.TrimPrefix(c.SignatureAlgorithm, "sha")
+		}
+		if c.SignatureEncoding == "" {
+			c.SignaturePrefix = c.SignatureAlgorithm + "="
+		}
+	}
+	if c.SignatureEncoding == "" {
+		c.SignatureEncoding = "hex"
+	}
+	return c
+}
+
+// WebhookSecret returns the secret for verifying webhook
+// signatures of a service from <SERVICE>_WEBHOOK_SECRET
+func WebhookSecret(service string) string {
+	service = strings.ReplaceAll(strings.ToUpper(service), "-", "_")
+	return os.Getenv(fmt.Sprintf("%s_WEBHOOK_SECRET", service))
+}
+
+// Sign returns the signature header value for a body
+func (c WebhookConfig) Sign(secret string, body []byte) (string, error) {
+	var newHash func() hash.Hash
+	switch c.SignatureAlgorithm {
+	case "sha256":
+		newHash = sha256.New
+	case "sha1":
+		newHash = sha1.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm: %s", c.SignatureAlgorithm)
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	sum := mac.Sum(nil)
+	if c.SignatureEncoding == "base64" {
+		return c.SignaturePrefix + base64.StdEncoding.EncodeToString(sum), nil
+	}
+	return c.SignaturePrefix + hex.EncodeToString(sum), nil
+}
+
+// Verify checks the signature of a webhook request body
+func (c WebhookConfig) Verify(secret string, header http.Header, body []byte) error {
+	got := header.Get(c.SignatureHeader)
+	if got == "" {
+		return fmt.Errorf("missing %s header", c.SignatureHeader)
+	}
+	want, err := c.Sign(secret, body)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		return fmt.Errorf("invalid signature in %s header", c.SignatureHeader)
+	}
+	return nil
+}
+
+// EventName returns the name of the event of a delivery
+func (c WebhookConfig) EventName(header http.Header, payload any) string {
+	var name string
+	if c.EventHeader != "" {
+		name = header.Get(c.EventHeader)
+	}
+	if c.EventAction != "" {
+		if action, ok := queryResponse(payload, c.EventAction); ok {
+			if name == "" {
+				return fmt.Sprint(action)
+			}
+			name += "-" + fmt.Sprint(action)
+		}
+	}
+	return name
+}
+
+// WebhookEvent is a webhook delivery received from a service
+type WebhookEvent struct {
+	// Name of the event, from its headers and payload
+	Name string
+
+	// Webhook is the declared webhook of the event, if found
+	Webhook Webhook
+
+	Header  http.Header
+	Body    []byte
+	Payload any
+
+	// Problems are where the payload doesn't match the webhook schema
+	Problems []string
+}
+
+// WebhookReceiver is an HTTP handler receiving webhook deliveries from a
+// service. Signatures are verified when Secret is set, and payloads are
+// validated against the schemas of the webhooks declared by the service.
+type WebhookReceiver struct {
+	Service Service
+	Config  WebhookConfig
+	Secret  string
+
+	// Strict rejects payloads that don't match their webhook schema
+	Strict bool
+
+	// MaxBodySize limits the size of deliveries, 25MB if zero
+	MaxBodySize int64
+
+	// Handle is called with each event received. An error
+	// responds with a server error so the provider can retry.
+	Handle func(e *WebhookEvent) error
+}
+
+// NewWebhookReceiver returns a receiver for a service using
+// its webhook configuration and secret from the environment
+func NewWebhookReceiver(s Service) *WebhookReceiver {
+	return &WebhookReceiver{
+		Service: s,
+		Config:  WebhookConfigFor(s),
+		Secret:  WebhookSecret(s.Name()),
+	}
+}
+
+func (rcv *WebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limit := rcv.MaxBodySize
+	if limit == 0 {
+		limit = 25 << 20
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rcv.Secret != "" {
+		if err := rcv.Config.Verify(rcv.Secret, r.Header, body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	event := &WebhookEvent{Header: r.Header, Body: body}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &event.Payload); err != nil {
+			http.Error(w, "payload is not JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	event.Name = rcv.Config.EventName(r.Header, event.Payload)
+	event.Webhook = rcv.webhook(event.Name)
+	if event.Webhook != nil {
+		if event.Name == "" {
+			event.Name = event.Webhook.Name()
+		}
+		if input := event.Webhook.Input(); input != nil {
+			event.Problems = ValidateValue(input, event.Payload)
+		}
+	}
+	if rcv.Strict && len(event.Problems) > 0 {
+		http.Error(w, "payload does not match schema:\n"+strings.Join(event.Problems, "\n"), http.StatusUnprocessableEntity)
+		return
+	}
+	if rcv.Handle != nil {
+		if err := rcv.Handle(event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// webhook finds the declared webhook for an event name. A service
+// declaring only one webhook uses it for events without a name.
+func (rcv *WebhookReceiver) webhook(name string) Webhook {
+	webhooks := rcv.Service.Webhooks()
+	if name == "" {
+		if len(webhooks) == 1 {
+			return webhooks[0]
+		}
+		return nil
+	}
+	var names []string
+	for _, wh := range webhooks {
+		names = append(names, wh.Name())
+	}
+	if !slices.Contains(names, name) {
+		if name, _ = resolveName(name, names); name == "" {
+			return nil
+		}
+	}
+	wh, err := rcv.Service.Webhook(name)
+	if err != nil {
+		return nil
+	}
+	return wh
+}
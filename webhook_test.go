@@ -0,0 +1,225 @@
+package integra
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tractor.dev/integra/internal/jsonaccess"
+)
+
+const testOpenAPIWebhooks = `
+openapi: 3.1.0
+info:
+  title: Test
+  version: "1.0"
+paths:
+  /subscriptions:
+    post:
+      callbacks:
+        statusChanged:
+          "{$request.body#/url}":
+            post:
+              requestBody:
+                content:
+                  application/json:
+                    schema:
+                      type: object
+                      properties:
+                        status:
+                          type: string
+      responses:
+        "201":
+          description: subscribed
+webhooks:
+  pet-created:
+    post:
+      summary: A pet was created
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                action:
+                  type: string
+                pet:
+                  type: object
+                  properties:
+                    id:
+                      type: integer
+                    status:
+                      type: string
+                      enum: [available, sold]
+                  required: [id]
+              required: [pet]
+`
+
+func TestWebhookReceiver(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIWebhooks)
+	s.meta = jsonaccess.New(map[string]any{
+		"latest": "1",
+		"webhooks": map[string]any{
+			"eventHeader": "X-Test-Event",
+			"eventAction": ".action",
+		},
+	})
+	if len(s.Webhooks()) != 2 {
+		t.Fatalf("expected webhook and callback, got %d", len(s.Webhooks()))
+	}
+
+	var received []*WebhookEvent
+	rcv := NewWebhookReceiver(s)
+	rcv.Secret = "shh"
+	rcv.Handle = func(e *WebhookEvent) error {
+		received = append(received, e)
+		return nil
+	}
+
+	deliver := func(body string, sign bool) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-Test-Event", "pet")
+		if sign {
+			sig, err := rcv.Config.Sign("shh", []byte(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Hub-Signature-256", sig)
+		}
+		rec := httptest.NewRecorder()
+		rcv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := deliver(`{"action":"created","pet":{"id":1}}`, false); code != http.StatusUnauthorized {
+		t.Errorf("unsigned delivery = %d; want 401", code)
+	}
+	if code := deliver(`{"action":"created","pet":{"id":1,"status":"available"}}`, true); code != http.StatusNoContent {
+		t.Fatalf("signed delivery = %d; want 204", code)
+	}
+	if len(received) != 1 || received[0].Name != "pet-created" || received[0].Webhook == nil || len(received[0].Problems) != 0 {
+		t.Fatalf("unexpected event: %+v", received)
+	}
+
+	if code := deliver(`{"action":"created","pet":{"id":"one","status":"lost"}}`, true); code != http.StatusNoContent {
+		t.Fatalf("invalid delivery = %d; want 204", code)
+	}
+	if problems := received[1].Problems; len(problems) != 2 {
+		t.Errorf("expected 2 problems, got %v", problems)
+	}
+
+	rcv.Strict = true
+	if code := deliver(`{"action":"created"}`, true); code != http.StatusUnprocessableEntity {
+		t.Errorf("strict invalid delivery = %d; want 422", code)
+	}
+
+	rcv.MaxBodySize = 16
+	if code := deliver(`{"action":"created","pet":{"id":1}}`, true); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large delivery = %d; want 413", code)
+	}
+}
+
+func TestWebhookConfigGitHub(t *testing.T) {
+	meta, err := loadServiceMeta("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := loadTestOpenAPI(t, testOpenAPIWebhooks)
+	s.meta = meta
+	c := WebhookConfigFor(s)
+	if c.EventHeader != "X-GitHub-Event" || c.SignatureHeader != "X-Hub-Signature-256" || c.EventAction != ".action" {
+		t.Errorf("unexpected github webhook config: %+v", c)
+	}
+}
+
+func TestWebhookConfigDefaults(t *testing.T) {
+	tests := []struct {
+		webhooks map[string]any
+		header   string
+		prefix   string
+	}{
+		{nil, "X-Hub-Signature-256", "sha256="},
+		{map[string]any{"signatureAlgorithm": "sha1"}, "X-Hub-Signature", "sha1="},
+		{map[string]any{"signatureAlgorithm": "sha512"}, "X-Hub-Signature-512", "sha512="},
+		{map[string]any{"signatureEncoding": "base64"}, "X-Hub-Signature-256", ""},
+		{map[string]any{"signatureHeader": "X-Signature"}, "X-Signature", ""},
+	}
+	s := loadTestOpenAPI(t, testOpenAPIWebhooks)
+	for _, test := range tests {
+		s.meta = jsonaccess.New(map[string]any{"webhooks": test.webhooks})
+		c := WebhookConfigFor(s)
+		if c.SignatureHeader != test.header || c.SignaturePrefix != test.prefix {
+			t.Errorf("config for %v = (%q, %q); want (%q, %q)", test.webhooks, c.SignatureHeader, c.SignaturePrefix, test.header, test.prefix)
+		}
+	}
+}
+
+const testOpenAPIWebhook = `
+openapi: 3.1.0
+info:
+  title: Test
+  version: "1.0"
+paths: {}
+webhooks:
+  pet-created:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                pet:
+                  type: object
+              required: [pet]
+`
+
+func TestWebhookReceiverSingleWebhook(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIWebhook)
+	s.meta = jsonaccess.New(map[string]any{"webhooks": map[string]any{"eventHeader": "X-Test-Event"}})
+	var received []*WebhookEvent
+	rcv := NewWebhookReceiver(s)
+	rcv.Strict = true
+	rcv.Handle = func(e *WebhookEvent) error {
+		received = append(received, e)
+		return nil
+	}
+	for _, event := range []string{"", "ping"} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"zen": "hi"}`))
+		if event != "" {
+			req.Header.Set("X-Test-Event", event)
+		}
+		rec := httptest.NewRecorder()
+		rcv.ServeHTTP(rec, req)
+		if event == "" && rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("unnamed delivery = %d; want 422 from the only webhook", rec.Code)
+		}
+		if event == "ping" && rec.Code != http.StatusNoContent {
+			t.Errorf("ping delivery = %d; want 204", rec.Code)
+		}
+	}
+	if len(received) != 1 || received[0].Name != "ping" || received[0].Webhook != nil {
+		t.Errorf("unexpected events: %+v", received)
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	pet := testOperation(t, s, "pet", "get").Output()
+	tests := []struct {
+		value    any
+		problems int
+	}{
+		{map[string]any{"id": float64(1), "name": "Rex", "status": "sold"}, 0},
+		{map[string]any{"id": 1.5, "name": nil, "extra": true}, 1},
+		{map[string]any{"id": "1", "name": float64(2), "status": "lost"}, 3},
+		{[]any{}, 1},
+	}
+	for _, test := range tests {
+		if problems := ValidateValue(pet, test.value); len(problems) != test.problems {
+			t.Errorf("ValidateValue(%v) = %v; want %d problems", test.value, problems, test.problems)
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package integra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"tractor.dev/integra/internal/jsonaccess"
+)
+
+// Workflow is a sequence of operations to perform, where the input of a
+// step can use the inputs of the workflow and outputs of previous steps.
+// Workflows are written in YAML or JSON:
+//
+//	inputs:
+//	  region: nyc3
+//	steps:
+//	  - id: droplet
+//	    call: digitalocean.droplet.create
+//	    wait: true
+//	    with:
+//	      name: web-${inputs.region}
+//	      region: ${inputs.region}
+//	  - id: tag
+//	    if: ${steps.droplet.droplet.status} != "off"
+//	    call: digitalocean.tag.create
+//	    with:
+//	      name: web
+//	  - id: volumes
+//	    call: digitalocean.volume.list
+//	    with:
+//	      region: ${inputs.region}
+//	  - forEach: ${steps.volumes.volumes}
+//	    as: volume
+//	    if: '!${volume.droplet_ids}'
+//	    call: digitalocean.volumeAction.create
+//	    with:
+//	      volume_id: ${volume.id}
+//	      droplet_id: ${steps.droplet.droplet.id}
+//	output: ${steps.droplet.droplet.id}
+//
+// Templates like ${steps.droplet.droplet.id} select into the inputs,
+// step outputs and loop variables with Query syntax. A string that is
+// only a template gives the selected value itself, otherwise templates
+// are replaced by their text.
+type Workflow struct {
+	Name   string         `json:"name"`
+	Inputs map[string]any `json:"inputs"`
+	Steps  []WorkflowStep `json:"steps"`
+
+	// Output is the result of the workflow, usually a template
+	Output any `json:"output"`
+}
+
+// WorkflowStep performs an operation, or a group of steps
+type WorkflowStep struct {
+	// ID names the output of the step for later steps
+	ID string `json:"id"`
+
+	// Call is the selector of the operation to perform
+	Call string `json:"call"`
+
+	// With is the input of the operation
+	With map[string]any `json:"with"`
+
+	// Wait polls an async operation until it finishes, and the
+	// output of the step is the last response polled
+	Wait bool `json:"wait"`
+
+	// If skips the step unless the condition is true. Conditions are a
+	// value, a value compared to another with ==, !=, <, <=, > or >=,
+	// or either negated with a leading !. With ForEach the condition
+	// is checked for each item, and skipped items output null.
+	If string `json:"if"`
+
+	// ForEach performs the step for each item of a list, named
+	// by As or item, with its position as index. The output of
+	// the step is the list of outputs.
+	ForEach any    `json:"forEach"`
+	As      string `json:"as"`
+
+	// Steps are performed in order instead of an operation
+	Steps []WorkflowStep `json:"steps"`
+}
+
+// ParseWorkflow parses a workflow from YAML or JSON
+func ParseWorkflow(b []byte) (*Workflow, error) {
+	var raw any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	raw = convertYAMLToStringMap(raw)
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var w Workflow
+	if err := json.Unmarshal(b, &w); err != nil {
+		return nil, err
+	}
+	if err := checkWorkflowFields(raw); err != nil {
+		return nil, err
+	}
+	if err := validateSteps(w.Steps); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// checkWorkflowFields returns an error for fields of a parsed workflow
+// or its steps that aren't known, since JSON decoding ignores them
+// and matches names regardless of case, so a typo like foreach would
+// silently change what a workflow does
+func checkWorkflowFields(raw any) error {
+	m, _ := raw.(map[string]any)
+	fields := jsonFieldNames[Workflow]()
+	for k := range m {
+		if !fields[k] {
+			return fmt.Errorf("unknown workflow field '%s'", k)
+		}
+	}
+	return checkStepFields(m["steps"])
+}
+
+func checkStepFields(raw any) error {
+	steps, _ := raw.([]any)
+	fields := jsonFieldNames[WorkflowStep]()
+	for i, step := range steps {
+		m, _ := step.(map[string]any)
+		for k := range m {
+			if !fields[k] {
+				name := fmt.Sprint(m["id"])
+				if m["id"] == nil {
+					name = strconv.Itoa(i + 1)
+				}
+				return fmt.Errorf("step %s: unknown field '%s'", name, k)
+			}
+		}
+		if err := checkStepFields(m["steps"]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonFieldNames returns the JSON names of the fields of struct T
+func jsonFieldNames[T any]() map[string]bool {
+	names := map[string]bool{}
+	t := reflect.TypeFor[T]()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names[name] = true
+	}
+	return names
+}
+
+func validateSteps(steps []WorkflowStep) error {
+	for i, step := range steps {
+		name := step.ID
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		if (step.Call == "") == (len(step.Steps) == 0) {
+			return fmt.Errorf("step %s: must have either call or steps", name)
+		}
+		if err := validateSteps(step.Steps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WorkflowRunner performs the steps of workflows
+type WorkflowRunner struct {
+	// Operation returns the operation for a selector. Defaults
+	// to resolving the selector against a loaded service.
+	Operation func(selector string) (Operation, error)
+
+	// Do performs operations, see DoFunc
+	Do DoFunc
+
+	// Logf reports the progress of steps, if set
+	Logf func(format string, args ...any)
+
+	// WaitTimeout limits waiting on a step, 10 minutes if zero
+	WaitTimeout time.Duration
+
+	// WaitInterval is the time between polls while waiting
+	// on a step, 5 seconds if zero
+	WaitInterval time.Duration
+}
+
+// Run performs the steps of a workflow with inputs merged over its
+// default inputs, and returns its output. Operations are performed
+// and waited on with ctx.
+func (r *WorkflowRunner) Run(ctx context.Context, w *Workflow, inputs map[string]any) (any, error) {
+	scope := map[string]any{
+		"inputs": MergeInput(w.Inputs, inputs),
+		"steps":  map[string]any{},
+	}
+	if err := r.runSteps(ctx, w.Steps, scope); err != nil {
+		return nil, err
+	}
+	return ExpandTemplates(w.Output, scope)
+}
+
+func (r *WorkflowRunner) runSteps(ctx context.Context, steps []WorkflowStep, scope map[string]any) error {
+	for _, step := range steps {
+		if err := r.runStep(ctx, step, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *WorkflowRunner) runStep(ctx context.Context, step WorkflowStep, scope map[string]any) error {
+	name := step.ID
+	if name == "" {
+		name = step.Call
+	}
+	if step.ForEach == nil {
+		ok, err := r.condition(step, name, scope)
+		if err != nil || !ok {
+			return err
+		}
+		out, err := r.perform(ctx, step, scope)
+		if err != nil {
+			return fmt.Errorf("step %s: %w", name, err)
+		}
+		if step.ID != "" {
+			scope["steps"].(map[string]any)[step.ID] = out
+		}
+		return nil
+	}
+
+	list, err := ExpandTemplates(step.ForEach, scope)
+	if err != nil {
+		return fmt.Errorf("step %s: %w", name, err)
+	}
+	items, ok := list.([]any)
+	if !ok && list != nil {
+		return fmt.Errorf("step %s: forEach must be a list, got %T", name, list)
+	}
+	as := step.As
+	if as == "" {
+		as = "item"
+	}
+	outputs := []any{}
+	for i, item := range items {
+		loopScope := make(map[string]any, len(scope)+2)
+		for k, v := range scope {
+			loopScope[k] = v
+		}
+		loopScope[as] = item
+		loopScope["index"] = i
+		ok, err := r.condition(step, fmt.Sprintf("%s[%d]", name, i), loopScope)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			outputs = append(outputs, nil)
+			continue
+		}
+		out, err := r.perform(ctx, step, loopScope)
+		if err != nil {
+			return fmt.Errorf("step %s[%d]: %w", name, i, err)
+		}
+		outputs = append(outputs, out)
+	}
+	if step.ID != "" {
+		scope["steps"].(map[string]any)[step.ID] = outputs
+	}
+	return nil
+}
+
+// condition reports whether the If of a step is true in scope
+func (r *WorkflowRunner) condition(step WorkflowStep, name string, scope map[string]any) (bool, error) {
+	if step.If == "" {
+		return true, nil
+	}
+	ok, err := evalCondition(step.If, scope)
+	if err != nil {
+		return false, fmt.Errorf("step %s: %w", name, err)
+	}
+	if !ok {
+		r.logf("%s: skipped", name)
+	}
+	return ok, nil
+}
+
+// perform does the operation of a step, or its steps, and returns its output
+func (r *WorkflowRunner) perform(ctx context.Context, step WorkflowStep, scope map[string]any) (any, error) {
+	if len(step.Steps) > 0 {
+		return nil, r.runSteps(ctx, step.Steps, scope)
+	}
+	op, err := r.operation(step.Call)
+	if err != nil {
+		return nil, err
+	}
+	in, err := ExpandTemplates(step.With, scope)
+	if err != nil {
+		return nil, err
+	}
+	input, _ := in.(map[string]any)
+	if input == nil {
+		input = map[string]any{}
+	}
+	resp, err := r.Do.orDefault()(ctx, op, input)
+	if err != nil {
+		return nil, err
+	}
+	r.logf("%s: %s", OperationSelector(op), resp.Status)
+	out, err := decodeResponse(resp)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	if !step.Wait {
+		return out.Data(), nil
+	}
+	async := AsyncOperationFor(op, out.Data())
+	if async == nil {
+		return out.Data(), nil
+	}
+	timeout := r.WaitTimeout
+	if timeout == 0 {
+		timeout = 10 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, final, err := async.Wait(ctx, op, out.Data(), AsyncWait{
+		Interval: r.WaitInterval,
+		Do:       r.Do,
+		Progress: func(state AsyncState) {
+			r.logf("%s: %s", OperationSelector(op), state.Status)
+		},
+	})
+	return final, err
+}
+
+func (r *WorkflowRunner) operation(selector string) (Operation, error) {
+	if r.Operation != nil {
+		return r.Operation(selector)
+	}
+	sel, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	s, err := sel.LoadService()
+	if err != nil {
+		return nil, err
+	}
+	return sel.ResolveOperation(s)
+}
+
+func (r *WorkflowRunner) logf(format string, args ...any) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
+
+var workflowTemplate = regexp.MustCompile(`\$\{\s*([^}]*?)\s*\}`)
+
+// ExpandTemplates replaces ${path} templates in the strings of
+// a value with values selected from scope by the path
+func ExpandTemplates(v any, scope map[string]any) (any, error) {
+	switch vv := v.(type) {
+	case string:
+		if m := workflowTemplate.FindStringSubmatchIndex(vv); m != nil && m[0] == 0 && m[1] == len(vv) {
+			return lookupTemplate(vv[m[2]:m[3]], scope)
+		}
+		var lookupErr error
+		expanded := workflowTemplate.ReplaceAllStringFunc(vv, func(t string) string {
+			v, err := lookupTemplate(workflowTemplate.FindStringSubmatch(t)[1], scope)
+			if err != nil {
+				lookupErr = err
+				return t
+			}
+			switch v.(type) {
+			case map[string]any, []any:
+				b, _ := json.Marshal(v)
+				return string(b)
+			case nil:
+				return ""
+			}
+			return fmt.Sprint(v)
+		})
+		return expanded, lookupErr
+	case map[string]any:
+		out := make(map[string]any, len(vv))
+		for k, e := range vv {
+			ev, err := ExpandTemplates(e, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = ev
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(vv))
+		for i, e := range vv {
+			ev, err := ExpandTemplates(e, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ev
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+func lookupTemplate(path string, scope map[string]any) (any, error) {
+	if !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+	v, err := jsonaccess.New(scope).Query(path)
+	if err != nil {
+		return nil, fmt.Errorf("${%s}: %w", strings.TrimPrefix(path, "."), err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.Data(), nil
+}
+
+var conditionOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// evalCondition evaluates the condition of a step
+func evalCondition(cond string, scope map[string]any) (bool, error) {
+	cond = strings.TrimSpace(cond)
+	if strings.HasPrefix(cond, "!") && !strings.HasPrefix(cond, "!=") {
+		ok, err := evalCondition(cond[1:], scope)
+		return !ok, err
+	}
+	// find an operator outside of templates
+	masked := workflowTemplate.ReplaceAllStringFunc(cond, func(t string) string {
+		return strings.Repeat("_", len(t))
+	})
+	for _, op := range conditionOperators {
+		i := strings.Index(masked, " "+op+" ")
+		if i < 0 {
+			continue
+		}
+		left, err := conditionOperand(cond[:i], scope)
+		if err != nil {
+			return false, err
+		}
+		right, err := conditionOperand(cond[i+len(op)+2:], scope)
+		if err != nil {
+			return false, err
+		}
+		return compareValues(left, op, right), nil
+	}
+	v, err := conditionOperand(cond, scope)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// conditionOperand returns the value of a template or literal, where
+// literals are parsed as YAML so "active", 3 and true have their types
+func conditionOperand(s string, scope map[string]any) (any, error) {
+	s = strings.TrimSpace(s)
+	if workflowTemplate.MatchString(s) {
+		return ExpandTemplates(s, scope)
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return s, nil
+	}
+	return convertYAMLToStringMap(v), nil
+}
+
+func compareValues(a any, op string, b any) bool {
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		switch op {
+		case "==":
+			return af == bf
+		case "!=":
+			return af != bf
+		case "<":
+			return af < bf
+		case "<=":
+			return af <= bf
+		case ">":
+			return af > bf
+		case ">=":
+			return af >= bf
+		}
+	}
+	as, bs := fmt.Sprint(a), fmt.Sprint(b)
+	switch op {
+	case "==":
+		return as == bs
+	case "!=":
+		return as != bs
+	case "<":
+		return as < bs
+	case "<=":
+		return as <= bs
+	case ">":
+		return as > bs
+	case ">=":
+		return as >= bs
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func truthy(v any) bool {
+	switch vv := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return vv
+	case string:
+		return vv != "" && vv != "false"
+	case []any:
+		return len(vv) > 0
+	case map[string]any:
+		return len(vv) > 0
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package integra
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testWorkflow = `
+inputs:
+  names: [Fido, Rex, Spot]
+  sell: Rex
+steps:
+  - id: created
+    forEach: ${inputs.names}
+    as: name
+    call: test.pet.create
+    with:
+      name: ${name}
+  - id: sold
+    forEach: ${steps.created}
+    as: pet
+    steps:
+      - if: ${pet.name} == ${inputs.sell}
+        id: deleted
+        call: test.pet.delete
+        with:
+          pet_id: ${pet.id}
+  - id: kept
+    forEach: ${steps.created}
+    as: pet
+    if: ${pet.name} != ${inputs.sell}
+    call: test.pet.get
+    with:
+      pet_id: ${pet.id}
+  - id: listing
+    call: test.pet.list
+  - if: ${steps.listing.pets[0].id} > 1
+    call: test.pet.delete
+    with:
+      pet_id: 1
+output:
+  pets: ${steps.listing.pets}
+  kept: ${steps.kept}
+  summary: first is ${steps.listing.pets[0].name} of ${inputs.names}
+`
+
+func TestWorkflow(t *testing.T) {
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	server := httptest.NewServer(NewMock(s))
+	defer server.Close()
+	s.SetBaseURL(server.URL)
+
+	w, err := ParseWorkflow([]byte(testWorkflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log []string
+	runner := &WorkflowRunner{
+		Operation: func(selector string) (Operation, error) {
+			sel, err := ParseSelector(selector)
+			if err != nil {
+				return nil, err
+			}
+			return sel.ResolveOperation(s)
+		},
+		Logf: func(format string, args ...any) {
+			log = append(log, strings.Fields(format)[0])
+		},
+	}
+	out, err := runner.Run(context.Background(), w, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := out.(map[string]any)
+	if pets := output["pets"].([]any); len(pets) != 2 {
+		t.Errorf("expected 2 pets left, got %v", pets)
+	}
+	if got, want := output["summary"], `first is Fido of ["Fido","Rex","Spot"]`; got != want {
+		t.Errorf("summary = %q; want %q", got, want)
+	}
+	if kept := output["kept"].([]any); len(kept) != 3 || kept[1] != nil || kept[2] == nil {
+		t.Errorf("expected Rex skipped in kept pets, got %v", kept)
+	}
+	// 3 creates, 1 delete and 2 skipped, 2 gets and 1 skipped, list, skipped delete
+	if len(log) != 11 {
+		t.Errorf("unexpected steps: %v", log)
+	}
+}
+
+func TestWorkflowErrors(t *testing.T) {
+	if _, err := ParseWorkflow([]byte("steps:\n  - id: empty\n")); err == nil {
+		t.Error("expected error for step without call or steps")
+	}
+	if _, err := ParseWorkflow([]byte("steps:\n  - foreach: [1, 2]\n    call: test.pet.get\n")); err == nil || !strings.Contains(err.Error(), "foreach") {
+		t.Errorf("expected error for unknown step field, got %v", err)
+	}
+	if _, err := ParseWorkflow([]byte("step:\n  - call: test.pet.get\n")); err == nil {
+		t.Error("expected error for unknown workflow field")
+	}
+
+	s := loadTestOpenAPI(t, testOpenAPIMock)
+	server := httptest.NewServer(NewMock(s))
+	defer server.Close()
+	s.SetBaseURL(server.URL)
+
+	w, err := ParseWorkflow([]byte("steps:\n  - id: missing\n    call: test.pet.get\n    with: {pet_id: 42}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := &WorkflowRunner{Operation: func(selector string) (Operation, error) {
+		sel, _ := ParseSelector(selector)
+		return sel.ResolveOperation(s)
+	}}
+	_, err = runner.Run(context.Background(), w, nil)
+	if err == nil || !strings.Contains(err.Error(), "step missing") || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEvalCondition(t *testing.T) {
+	scope := map[string]any{
+		"inputs": map[string]any{"count": float64(3), "name": "web", "empty": []any{}},
+	}
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{"${inputs.count} > 2", true},
+		{"${inputs.count} <= 2", false},
+		{`${inputs.name} == "web"`, true},
+		{"${inputs.name} != web", false},
+		{"${inputs.empty}", false},
+		{"!${inputs.empty}", true},
+		{"${inputs.missing}", false},
+		{"true", true},
+	}
+	for _, test := range tests {
+		got, err := evalCondition(test.cond, scope)
+		if err != nil {
+			t.Fatalf("%s: %v", test.cond, err)
+		}
+		if got != test.want {
+			t.Errorf("%s = %v; want %v", test.cond, got, test.want)
+		}
+	}
+}